@@ -14,9 +14,10 @@
 package cmd
 
 import (
-	"github.com/VirusTotal/vt-cli/utils"
+	"context"
 	"regexp"
 
+	"github.com/VirusTotal/vt-cli/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -61,5 +62,76 @@ func NewAnalysisCmd() *cobra.Command {
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
 
+	cmd.AddCommand(NewAnalysisWaitCmd())
+
+	return cmd
+}
+
+type analysisWaiter struct {
+	cli *utils.APIClient
+}
+
+func (w *analysisWaiter) Do(ctx context.Context, id interface{}, ds *utils.DoerState) *utils.DoerResult {
+	obj, err := waitForAnalysisResults(ctx, w.cli, id.(string), ds)
+	if err != nil {
+		return utils.NewDoerError(id.(string), err)
+	}
+	res := utils.NewDoerResult(id.(string), "completed")
+	res.Object = obj
+	return res
+}
+
+var analysisWaitCmdHelp = `Wait for one or more analyses to complete.
+
+This command receives one or more analysis identifiers, like the ones returned
+by "vt scan file" and "vt scan url" without --wait, waits until the analyses
+are completed and prints the analysed files or URLs. The analyses are waited
+for in parallel, use --threads for controlling how many of them are polled at
+the same time.
+
+The API is polled every --poll-interval at first, and the interval grows after
+each check up to --max-poll-interval. Analyses that are not completed after
+--wait-timeout are reported as errors.
+
+If the command receives a single hypen (-) the analysis identifiers are read
+from the standard input, one per line.`
+
+var analysisWaitCmdExample = `  vt analysis wait f-e04b82f7f8afc6e599d4913bee5eb571921ec8958d1ea5e3bbffe9c7ea9a0960-1542306475
+  vt scan file *.exe | cut -d' ' -f2 | vt analysis wait --wait-timeout 30m -`
+
+// NewAnalysisWaitCmd returns a new instance of the 'analysis wait' command.
+func NewAnalysisWaitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "wait [analysis id]...",
+		Short:   "Wait for analyses to complete",
+		Long:    analysisWaitCmdHelp,
+		Example: analysisWaitCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			ctx, stop := utils.WithInterrupt(cmd.Context())
+			defer stop()
+			c.DoWithStringsFromReader(ctx, &analysisWaiter{cli: client},
+				utils.StringReaderFromCmdArgs(args))
+			return nil
+		},
+	}
+
+	// Waiting is mostly idle, so more analyses are waited for in parallel
+	// than the default for other commands.
+	cmd.Flags().IntP(
+		"threads", "t", 20,
+		"number of analyses waited for in parallel")
+	addIncludeExcludeFlags(cmd.Flags())
+	addPollFlags(cmd.Flags())
+
 	return cmd
 }
@@ -0,0 +1,262 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	grab "github.com/cavaliergopher/grab/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// behaviourIDRe matches the IDs of sandbox reports, which have the form
+// <sha256>_<sandbox name>.
+var behaviourIDRe = regexp.MustCompile(`^[0-9a-f]{64}_[^/\\]+$`)
+
+// artifactExtensions maps the kinds of artifacts produced by sandboxes to the
+// extension of the files where they are saved.
+var artifactExtensions = map[string]string{
+	"pcap":    ".pcap",
+	"evtx":    ".evtx",
+	"memdump": ".dmp",
+	"html":    ".html",
+}
+
+var fileBehavioursCmdHelp = `Get the sandbox reports for a file.
+
+This command lists the reports produced by each of the sandboxes that
+executed the file. The ID of each report, which has the form
+<sha256>_<sandbox name>, can be used with "vt download artifact" for
+downloading the artifacts produced by the sandbox, like network traffic
+captures.`
+
+var fileBehavioursCmdExample = `  vt file behaviours 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
+  vt file behaviours --include sandbox_name,verdicts 44d88612fea8a8f36de82e1278abb02f
+  vt file behaviours -I --format csv 44d88612fea8a8f36de82e1278abb02f | vt download artifact --kind pcap -`
+
+// NewFileBehavioursCmd returns a new instance of the 'file behaviours' command.
+func NewFileBehavioursCmd() *cobra.Command {
+	cmd := NewRelationshipCmd("files", "behaviours", "[hash]",
+		"Get the sandbox reports for a file")
+	cmd.Long = fileBehavioursCmdHelp
+	cmd.Example = fileBehavioursCmdExample
+	return cmd
+}
+
+var fileBehaviourSummaryCmdHelp = `Get a summary of the sandbox reports for a file.
+
+This command merges the reports produced by all the sandboxes that executed
+the file in a single summary, with the processes, files, registry keys,
+network connections and other activity observed by any of them.`
+
+var fileBehaviourSummaryCmdExample = `  vt file behaviour-summary 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
+  vt file behaviour-summary --include 'dns_lookups.*,ip_traffic.*' 44d88612fea8a8f36de82e1278abb02f`
+
+// NewFileBehaviourSummaryCmd returns a new instance of the
+// 'file behaviour-summary' command.
+func NewFileBehaviourSummaryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "behaviour-summary [hash]",
+		Short:   "Get a summary of the sandbox reports for a file",
+		Long:    fileBehaviourSummaryCmdHelp,
+		Example: fileBehaviourSummaryCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			var summary map[string]interface{}
+			if _, err := client.GetData(vt.URL("files/%s/behaviour_summary", args[0]), &summary); err != nil {
+				return err
+			}
+			if viper.IsSet("include") || viper.IsSet("exclude") {
+				summary = utils.FilterMap(summary,
+					viper.GetStringSlice("include"),
+					viper.GetStringSlice("exclude"))
+			}
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.Print(summary)
+		},
+	}
+
+	addIncludeExcludeFlags(cmd.Flags())
+
+	return cmd
+}
+
+var fileMitreCmdHelp = `Get the MITRE ATT&CK tactics and techniques observed for a file.
+
+This command prints a tree with the MITRE ATT&CK tactics and techniques
+observed by each of the sandboxes that executed the file. Each technique is
+followed by the highest severity of the sandbox signatures that matched it,
+use --signatures for including the signatures themselves. With --format the
+trees are printed in the given format instead.`
+
+var fileMitreCmdExample = `  vt file mitre 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
+  vt file mitre --signatures 44d88612fea8a8f36de82e1278abb02f
+  vt file mitre --format json 44d88612fea8a8f36de82e1278abb02f`
+
+// NewFileMitreCmd returns a new instance of the 'file mitre' command.
+func NewFileMitreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "mitre [hash]",
+		Short:   "Get the MITRE ATT&CK tactics and techniques observed for a file",
+		Long:    fileMitreCmdHelp,
+		Example: fileMitreCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			url := vt.URL("files/%s/behaviour_mitre_trees", args[0])
+			if viper.IsSet("format") {
+				var trees map[string]interface{}
+				if _, err := client.GetData(url, &trees); err != nil {
+					return err
+				}
+				p, err := NewPrinter(cmd)
+				if err != nil {
+					return err
+				}
+				return p.Print(trees)
+			}
+			var trees map[string]utils.MitreTree
+			if _, err := client.GetData(url, &trees); err != nil {
+				return err
+			}
+			utils.PrintMitreTrees(os.Stdout, trees, viper.GetBool("signatures"))
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool(
+		"signatures", false,
+		"include the sandbox signatures that matched each technique")
+
+	return cmd
+}
+
+// artifactDownloader is a Doer that downloads the artifacts of a given kind
+// produced by sandboxes, receiving the IDs of sandbox reports.
+type artifactDownloader struct {
+	fileDownloader
+	kind string
+}
+
+func (a *artifactDownloader) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	id := item.(string)
+	if !behaviourIDRe.MatchString(id) {
+		return utils.NewDoerError(id, fmt.Errorf("invalid sandbox report ID %q", id))
+	}
+	ds.Progress = fmt.Sprintf("%s %4.1f%%", id, 0.0)
+
+	var size int64
+	dstPath := filepath.Join(viper.GetString("output"),
+		utils.SanitizeFileName(id+artifactExtensions[a.kind]))
+	downloadURL := vt.URL("file_behaviours/%s/%s", url.PathEscape(id), a.kind)
+	err := a.DownloadFile(ctx, downloadURL.String(), dstPath, func(resp *grab.Response) {
+		size = resp.BytesComplete()
+		if progress := 100 * resp.Progress(); progress < 100 {
+			ds.Progress = fmt.Sprintf("%s %4.1f%% %6.1f KBi/s",
+				id, progress, resp.BytesPerSecond()/1024)
+		}
+	})
+	if err != nil {
+		return downloadResult(id, "", size, err)
+	}
+	return downloadResult(id, dstPath, size, nil)
+}
+
+var downloadArtifactCmdHelp = `Download artifacts produced by sandboxes.
+
+This command receives the IDs of one or more sandbox reports, as listed by
+"vt file behaviours", and downloads the artifacts of the kind specified with
+--kind: network traffic captures (pcap), Windows event logs (evtx), memory
+dumps (memdump) or the HTML version of the report (html). Artifacts are saved
+in the --output directory, named after the report ID with an extension that
+depends on the kind, like <sha256>_Zenbox.pcap.
+
+If the command receives a single hypen (-) the IDs are read from the standard
+input, one per line.`
+
+var downloadArtifactCmdExample = `  vt download artifact --kind pcap 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85_Zenbox
+  vt file behaviours -I --format csv 44d88612fea8a8f36de82e1278abb02f | vt download artifact --kind evtx -o incident/ -`
+
+// NewDownloadArtifactCmd returns a new instance of the 'download artifact'
+// command.
+func NewDownloadArtifactCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "artifact [behaviour id]...",
+		Short:   "Download artifacts produced by sandboxes",
+		Long:    downloadArtifactCmdHelp,
+		Example: downloadArtifactCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind := viper.GetString("kind")
+			if _, ok := artifactExtensions[kind]; !ok {
+				return fmt.Errorf("invalid kind %q, use pcap, evtx, memdump or html", kind)
+			}
+			if err := os.MkdirAll(viper.GetString("output"), 0o755); err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			fd, err := newFileDownloader(client)
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, stop := utils.WithInterrupt(cmd.Context())
+			defer stop()
+			c.DoWithStringsFromReader(ctx,
+				&artifactDownloader{fileDownloader: fd, kind: kind},
+				utils.StringReaderFromCmdArgs(args))
+			if utils.Interrupted(ctx) {
+				cmd.SilenceUsage = true
+				return errors.New("interrupted")
+			}
+			return nil
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addOutputFlag(cmd.Flags())
+	cmd.Flags().String(
+		"kind", "pcap",
+		"kind of artifact: pcap, evtx, memdump or html")
+
+	return cmd
+}
@@ -14,14 +14,20 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 
 	"github.com/VirusTotal/vt-cli/utils"
 	"github.com/VirusTotal/vt-cli/yaml"
@@ -51,11 +57,40 @@ func addHostFlag(flags *pflag.FlagSet) {
 	flags.MarkHidden("host")
 }
 
-func addProxyFlag(flags *pflag.FlagSet) {
+func addProxyFlags(flags *pflag.FlagSet) {
 	flags.String(
 		"proxy", "",
-		"HTTP proxy")
-	flags.MarkHidden("proxy")
+		"proxy URL (http://, https:// or socks5://)")
+	flags.String(
+		"proxy-user", "",
+		"proxy credentials in user:password format")
+	flags.String(
+		"no-proxy", "",
+		"comma-separated list of hosts that bypass the proxy")
+}
+
+func addTimeoutFlag(flags *pflag.FlagSet) {
+	flags.Duration(
+		"timeout", 0,
+		"timeout for connecting and waiting for server responses (e.g. 30s)")
+}
+
+func addTLSFlags(flags *pflag.FlagSet) {
+	flags.String(
+		"ca-file", "",
+		"PEM file with additional CA certificates to trust")
+	flags.String(
+		"client-cert", "",
+		"PEM file with a client certificate for TLS authentication")
+	flags.String(
+		"client-key", "",
+		"PEM file with the private key for --client-cert")
+}
+
+func addProfileFlag(flags *pflag.FlagSet) {
+	flags.String(
+		"profile", "",
+		"use settings from the given profile in the config file")
 }
 
 func addIncludeExcludeFlags(flags *pflag.FlagSet) {
@@ -110,6 +145,15 @@ func addVerboseFlag(flags *pflag.FlagSet) {
 		"verbose output")
 }
 
+func addTraceFlags(flags *pflag.FlagSet) {
+	flags.Bool(
+		"trace", false,
+		"print every HTTP request and response to stderr")
+	flags.String(
+		"log-file", "",
+		"append log messages and HTTP traces to this file")
+}
+
 func addSilentFlag(flags *pflag.FlagSet) {
 	flags.BoolP(
 		"silent", "s", false,
@@ -122,6 +166,336 @@ func addHumanFlag(flags *pflag.FlagSet) {
 		"output in a human-friendly format")
 }
 
+func addJournalFlags(flags *pflag.FlagSet) {
+	flags.String(
+		"journal", "",
+		"file where completed and pending items are recorded")
+	flags.String(
+		"resume", "",
+		"resume the work recorded in the given journal file")
+}
+
+func addExtractFlag(flags *pflag.FlagSet) {
+	flags.BoolP(
+		"extract", "e", false,
+		"extract indicators from free text, like \"vt extract\" does")
+}
+
+// addWalkFlags adds the flags that control how directories are walked,
+// recursive is the default value for --recursive.
+func addWalkFlags(flags *pflag.FlagSet, recursive bool) {
+	flags.BoolP(
+		"recursive", "r", recursive,
+		"walk directories recursively")
+	flags.StringSlice(
+		"include-files", []string{},
+		"include only files matching the provided pattern when walking directories (e.g. '*.exe')")
+	flags.StringSlice(
+		"exclude-files", []string{},
+		"exclude files and directories matching the provided pattern when walking directories")
+	flags.String(
+		"max-size", "",
+		"skip files larger than this size (e.g. 32MB)")
+	flags.Bool(
+		"follow-symlinks", false,
+		"follow symbolic links found while walking directories")
+}
+
+// newWalker returns a utils.Walker configured with the flags added by
+// addWalkFlags.
+func newWalker() (*utils.Walker, error) {
+	maxSize, err := utils.ParseSize(viper.GetString("max-size"))
+	if err != nil {
+		return nil, err
+	}
+	return &utils.Walker{
+		Recursive:      viper.GetBool("recursive"),
+		Include:        viper.GetStringSlice("include-files"),
+		Exclude:        viper.GetStringSlice("exclude-files"),
+		MaxSize:        maxSize,
+		FollowSymlinks: viper.GetBool("follow-symlinks"),
+	}, nil
+}
+
+func addDedupeFlag(flags *pflag.FlagSet, dedupe bool) {
+	flags.Bool(
+		"dedupe", dedupe,
+		"process only one file among the ones with the same content")
+}
+
+// collectFiles returns the files found in paths with a walker configured with
+// the flags added by addWalkFlags and addDedupeFlag. Unless --silent was used,
+// a summary with the number of files and their total size is printed to the
+// standard error.
+func collectFiles(paths []string) (*utils.FileSet, error) {
+	w, err := newWalker()
+	if err != nil {
+		return nil, err
+	}
+	set, err := w.Collect(paths, viper.GetBool("dedupe"))
+	if err != nil {
+		return nil, err
+	}
+	if !viper.GetBool("silent") {
+		summary := fmt.Sprintf("%d files, %s in total",
+			len(set.Paths), humanize.Bytes(uint64(set.Size)))
+		if set.Duplicates > 0 {
+			summary += fmt.Sprintf(", %d duplicates skipped", set.Duplicates)
+		}
+		color.New(color.Faint).Fprintln(os.Stderr, summary)
+	}
+	return set, nil
+}
+
+func addFailOnFlag(flags *pflag.FlagSet) {
+	flags.String(
+		"fail-on", "",
+		"exit with code 2 if any item matches this policy (e.g. 'malicious>=3 or suspicious>=5')")
+}
+
+// Exit codes used by commands that accept --fail-on.
+const (
+	// ExitCodeError means that some item couldn't be evaluated.
+	ExitCodeError = 1
+	// ExitCodeFlagged means that some item matched the --fail-on policy.
+	ExitCodeFlagged = 2
+)
+
+// ExitError is an error that makes the program exit with a specific code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newGate returns a utils.Gate for the policy specified with --fail-on, or nil
+// if the flag was not used.
+func newGate() (*utils.Gate, error) {
+	expr := viper.GetString("fail-on")
+	if expr == "" {
+		return nil, nil
+	}
+	p, err := utils.ParsePolicy(expr)
+	if err != nil {
+		return nil, err
+	}
+	return utils.NewGate(p), nil
+}
+
+// gateError returns the error that the command must return after checking the
+// items it processed with gate. If some item was flagged the error has the
+// ExitCodeFlagged code, if some item couldn't be checked it has ExitCodeError.
+// If all items were clean, or gate is nil, the error is nil.
+func gateError(cmd *cobra.Command, gate *utils.Gate) error {
+	if gate == nil {
+		return nil
+	}
+	_, flagged, failed := gate.Counts()
+	cmd.SilenceUsage = true
+	if flagged > 0 {
+		return &ExitError{ExitCodeFlagged, fmt.Errorf(
+			"%d items flagged by policy \"%s\"", flagged, gate.Policy)}
+	}
+	if failed > 0 {
+		return &ExitError{ExitCodeError, fmt.Errorf(
+			"%d items couldn't be checked against policy \"%s\"", failed, gate.Policy)}
+	}
+	return nil
+}
+
+// stringReaderFromCmdArgs works like utils.StringReaderFromCmdArgs, but if
+// --extract was used the returned reader extracts indicators of the given
+// types from the arguments or the standard input, instead of taking each
+// line as is.
+func stringReaderFromCmdArgs(args []string, types ...utils.IOCType) utils.StringReader {
+	r := utils.StringReaderFromCmdArgs(args)
+	if viper.GetBool("extract") {
+		return utils.NewIOCReader(r, types...)
+	}
+	return r
+}
+
+func addInputFlags(flags *pflag.FlagSet) {
+	flags.String(
+		"input-format", "text",
+		"format of the input: text (one item per line), csv, tsv or jsonl")
+	flags.String(
+		"input-field", "",
+		"field with the items in csv/tsv input (number starting at 1 or column name) or jsonl input (path like src.ip)")
+	flags.Bool(
+		"input-header", false,
+		"the first row of csv/tsv input is a header")
+	flags.Bool(
+		"with-input", false,
+		"add the input record from which each item was read to the output as _input")
+}
+
+// openTextFiles returns a reader for the text in the given files, or for the
+// standard input if no files are given or files consists in a single hypen.
+func openTextFiles(files []string) (io.Reader, func(), error) {
+	if len(files) == 0 || (len(files) == 1 && files[0] == "-") {
+		return os.Stdin, func() {}, nil
+	}
+	readers := make([]io.Reader, 0)
+	opened := make([]*os.File, 0)
+	closeAll := func() {
+		for _, f := range opened {
+			f.Close()
+		}
+	}
+	for _, filename := range files {
+		f, err := os.Open(filename)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		opened = append(opened, f)
+		// Make sure that the last line of a file is not merged with the first
+		// line of the next one.
+		readers = append(readers, f, strings.NewReader("\n"))
+	}
+	return io.MultiReader(readers...), closeAll, nil
+}
+
+// inputReaderFromCmdArgs returns a reader for the items passed to a command
+// that accepts --input-format. For the "text" format the reader is the same
+// returned by stringReaderFromCmdArgs, for other formats args must contain a
+// single file name, or a hypen (-) for reading from the standard input, and
+// the items are read from the field specified with --input-field. The returned
+// function must be called when the reader is not needed anymore.
+func inputReaderFromCmdArgs(args []string, types ...utils.IOCType) (utils.StringReader, func(), error) {
+	format := strings.ToLower(viper.GetString("input-format"))
+	if format == "" || format == "text" {
+		return stringReaderFromCmdArgs(args, types...), func() {}, nil
+	}
+	if format != "csv" && format != "tsv" && format != "jsonl" {
+		return nil, nil, fmt.Errorf("unknown input format %q, use text, csv, tsv or jsonl", format)
+	}
+	if viper.GetBool("extract") {
+		return nil, nil, fmt.Errorf("--extract can't be used with --input-format %s", format)
+	}
+	if len(args) != 1 {
+		return nil, nil, fmt.Errorf(
+			"--input-format %s requires a single file, or - for reading from stdin", format)
+	}
+	in, closeFn, err := openTextFiles(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r utils.StringReader
+	field := viper.GetString("input-field")
+	switch format {
+	case "csv":
+		r, err = utils.NewCSVReader(in, ',', field, viper.GetBool("input-header"))
+	case "tsv":
+		r, err = utils.NewCSVReader(in, '\t', field, viper.GetBool("input-header"))
+	case "jsonl":
+		r, err = utils.NewJSONLinesReader(in, field)
+	}
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	return r, closeFn, nil
+}
+
+// minimumNArgsUnlessResume works like cobra.MinimumNArgs, but doesn't require
+// any argument when --resume is used.
+func minimumNArgsUnlessResume(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("resume") {
+			return nil
+		}
+		return cobra.MinimumNArgs(n)(cmd, args)
+	}
+}
+
+// openJournal returns the journal for a command that supports --resume and
+// --journal. If --resume was used, the journal is loaded from the given file
+// and the returned StringReader contains the items that were pending in that
+// journal, if not, a new journal is created and the reader is nil.
+func openJournal(cmd *cobra.Command) (*utils.Journal, utils.StringReader, error) {
+	resume := viper.GetString("resume")
+	if resume == "" {
+		return utils.NewJournal(cmd.CommandPath()), nil, nil
+	}
+	j, err := utils.LoadJournal(resume)
+	if err != nil {
+		return nil, nil, err
+	}
+	if j.Command != cmd.CommandPath() {
+		return nil, nil, fmt.Errorf(
+			"journal %s was created by \"%s\", not by \"%s\"",
+			resume, j.Command, cmd.CommandPath())
+	}
+	return j, utils.NewStringArrayReader(j.TakePending()), nil
+}
+
+// doWithJournal calls run with a context in which the first interrupt signal
+// stops the processing of new items, and the second one aborts the items in
+// progress. run must use c for processing the items. If the work is
+// interrupted, or the --journal or --resume flags were used, the journal with
+// the completed, failed and pending items is saved, so that the work can be
+// resumed later.
+func doWithJournal(cmd *cobra.Command, c *utils.Coordinator, j *utils.Journal, run func(context.Context)) error {
+	ctx, stop := utils.WithInterrupt(cmd.Context())
+	defer stop()
+
+	c.Journal = j
+	run(ctx)
+
+	interrupted := utils.Interrupted(ctx)
+
+	filename := viper.GetString("journal")
+	if filename == "" {
+		filename = viper.GetString("resume")
+	}
+	if filename == "" && interrupted {
+		filename = fmt.Sprintf("vt-%s.journal", time.Now().Format("20060102-150405"))
+	}
+	if filename == "" {
+		return nil
+	}
+	if err := j.Save(filename); err != nil {
+		return err
+	}
+	if interrupted {
+		utils.Warnf(
+			"%d items pending and %d failed, journal written to %s. Use --resume %s to continue.",
+			len(j.Pending), len(j.Failed), filename, filename)
+		if j.Cursor != "" {
+			utils.Warnf(
+				"The input was not read completely, use --cursor %s for continuing it.", j.Cursor)
+		} else if j.Truncated {
+			utils.Warnf(
+				"The input was not read completely, the items after the pending ones are not in the journal.")
+		}
+		cmd.SilenceUsage = true
+		return errors.New("interrupted")
+	}
+	if len(j.Failed) > 0 {
+		utils.Warnf(
+			"%d items failed, journal written to %s. Use --resume %s to retry them.",
+			len(j.Failed), filename, filename)
+	}
+	return nil
+}
+
+// doWithJournalFromReader is like doWithJournal, but calls doer with the items
+// read from a StringReader.
+func doWithJournalFromReader(cmd *cobra.Command, c *utils.Coordinator, j *utils.Journal, doer utils.Doer, r utils.StringReader) error {
+	return doWithJournal(cmd, c, j, func(ctx context.Context) {
+		c.DoWithStringsFromReader(ctx, doer, r)
+	})
+}
+
 // ReadFile reads the specified file and returns its content. If filename is "-"
 // the data is read from stdin.
 func ReadFile(filename string) ([]byte, error) {
@@ -136,6 +510,19 @@ func NewAPIClient() (*utils.APIClient, error) {
 	return utils.NewAPIClient(fmt.Sprintf("vt-cli %s", Version))
 }
 
+// NewCoordinator creates a new utils.Coordinator that uses the number of
+// threads specified with --threads, and prints the results with a printer
+// for the given command.
+func NewCoordinator(cmd *cobra.Command) (*utils.Coordinator, error) {
+	p, err := NewPrinter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	c := utils.NewCoordinator(viper.GetInt("threads"))
+	c.Printer = p
+	return c, nil
+}
+
 // NewPrinter creates a new utils.Printer.
 func NewPrinter(cmd *cobra.Command) (*utils.Printer, error) {
 	client, err := NewAPIClient()
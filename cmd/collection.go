@@ -74,11 +74,19 @@ This command receives one of more IoCs (sha256 hashes, URLs, domains, IP address
 and creates a collection from them.
 
 If the command receives a single hypen (-) the IoCs will be read from the
-standard input.`
+standard input. With --extract the IoCs are extracted from free text, like
+reports or logs, instead of being read one per line.`
 
 var createCollectionExample = `  vt collection create -n [collection_name] -d [collection_description] www.example.com
   vt collection create -n [collection_name] -d [collection_description] www.example.com 8.8.8.8
-  cat list_of_iocs | vt collection create -n [collection_name] -d [collection_description] -`
+  cat list_of_iocs | vt collection create -n [collection_name] -d [collection_description] -
+  cat report.txt | vt collection create -n [collection_name] -d [collection_description] --extract -`
+
+// Types of the indicators extracted from free text with --extract. These are
+// the types of the objects that can be added to a collection.
+var collectionIOCTypes = []utils.IOCType{
+	utils.IOCMD5, utils.IOCSHA1, utils.IOCSHA256,
+	utils.IOCURL, utils.IOCDomain, utils.IOCIPv4}
 
 // NewCollectionCreateCmd returns a command for creating a collection.
 func NewCollectionCreateCmd() *cobra.Command {
@@ -98,7 +106,7 @@ func NewCollectionCreateCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			reader := utils.StringReaderFromCmdArgs(args)
+			reader := stringReaderFromCmdArgs(args, collectionIOCTypes...)
 
 			collection := vt.NewObject("collection")
 			collection.SetString("name", viper.GetString("name"))
@@ -131,6 +139,7 @@ func NewCollectionCreateCmd() *cobra.Command {
 	_ = cmd.MarkFlagRequired("description")
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
 
 	return cmd
 }
@@ -164,7 +173,8 @@ This command receives a collection ID and one of more IoCs
 (sha256 hashes, URLs, domains, IP addresses) and adds them to the collection.
 
 If the command receives a single hypen (-) the IoCs will be read from the
-standard input.`
+standard input. With --extract the IoCs are extracted from free text, like
+reports or logs, instead of being read one per line.`
 
 var updateCollectionExample = `  vt collection update [collection id] www.example.com
   vt collection update [collection id] www.example.com 8.8.8.8
@@ -172,7 +182,7 @@ var updateCollectionExample = `  vt collection update [collection id] www.exampl
 
 // NewCollectionUpdateCmd returns a command for adding new items to a collection.
 func NewCollectionUpdateCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:     "update [collection id] [ioc]...",
 		Short:   "Add new items to a collection.",
 		Args:    cobra.MinimumNArgs(2),
@@ -190,7 +200,7 @@ func NewCollectionUpdateCmd() *cobra.Command {
 			}
 
 			collection := vt.NewObjectWithID("collection", args[0])
-			reader := utils.StringReaderFromCmdArgs(args[1:])
+			reader := stringReaderFromCmdArgs(args[1:], collectionIOCTypes...)
 			collection.SetData("raw_items", rawFromReader(reader))
 
 			if err := c.PatchObject(vt.URL("collections/%s", args[0]), collection); err != nil {
@@ -208,6 +218,10 @@ func NewCollectionUpdateCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	addExtractFlag(cmd.Flags())
+
+	return cmd
 }
 
 var removeCollectionItemsCmdHelp = `Remove items from a collection.
@@ -216,7 +230,8 @@ This command receives a collection ID and one of more IoCs
 (sha256 hashes, URLs, domains, IP addresses) and removes them from the collection.
 
 If the command receives a single hypen (-) the IoCs will be read from the
-standard input.`
+standard input. With --extract the IoCs are extracted from free text, like
+reports or logs, instead of being read one per line.`
 
 var removeCollectionItemsExample = `  vt collection remove [collection id] www.example.com
   vt collection remove [collection id] www.example.com 8.8.8.8
@@ -224,7 +239,7 @@ var removeCollectionItemsExample = `  vt collection remove [collection id] www.e
 
 // NewCollectionRemoveItemsCmd returns a command for removing items from a collection.
 func NewCollectionRemoveItemsCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:     "remove [collection id] [ioc]...",
 		Short:   "Remove items from a collection.",
 		Args:    cobra.MinimumNArgs(2),
@@ -237,7 +252,7 @@ func NewCollectionRemoveItemsCmd() *cobra.Command {
 				return err
 			}
 			relationshipDescriptors := descriptorsFromReader(
-				utils.StringReaderFromCmdArgs(args[1:]))
+				stringReaderFromCmdArgs(args[1:], collectionIOCTypes...))
 			for relationshipName, descriptors := range relationshipDescriptors {
 				url := vt.URL("collections/%s/%s", args[0], relationshipName)
 				response, err := c.DeleteData(url, descriptors)
@@ -251,6 +266,10 @@ func NewCollectionRemoveItemsCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	addExtractFlag(cmd.Flags())
+
+	return cmd
 }
 
 var deleteCollectionCmdHelp = `Delete a collection.
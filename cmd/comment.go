@@ -0,0 +1,284 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// apiResult returns the result for an item processed with an API request
+// that failed with err, or an "ok" result if err is nil.
+func apiResult(item string, err error) *utils.DoerResult {
+	if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+		return &utils.DoerResult{Item: item, Status: "not found", Error: err}
+	} else if err != nil {
+		return utils.NewDoerError(item, err)
+	}
+	return utils.NewDoerResult(item, "ok")
+}
+
+// runWithJournal calls doer with the items passed to the command, which are
+// read from the standard input if the only argument is a hyphen (-), or with
+// the pending items in the journal if --resume was used.
+func runWithJournal(cmd *cobra.Command, args []string, doer utils.Doer) error {
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
+	journal, argReader, err := openJournal(cmd)
+	if err != nil {
+		return err
+	}
+	if argReader == nil {
+		argReader = utils.StringReaderFromCmdArgs(args)
+	}
+	return doWithJournalFromReader(cmd, c, journal, doer, argReader)
+}
+
+// commentAdder is a Doer that adds the same comment to multiple objects.
+type commentAdder struct {
+	cli        *utils.APIClient
+	objectType string
+	text       string
+}
+
+func (a *commentAdder) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	s := item.(string)
+	path, err := objectPath(a.objectType, s)
+	if err != nil {
+		return utils.NewDoerError(s, err)
+	}
+	ds.Progress = fmt.Sprintf("%s adding comment...", s)
+	comment := vt.NewObject("comment")
+	comment.SetString("text", a.text)
+	if err := a.cli.PostObject(vt.URL("%s/comments", path), comment); err != nil {
+		return apiResult(s, err)
+	}
+	res := apiResult(s, nil).With("comment_id", comment.ID())
+	res.Text = fmt.Sprintf("%s %s", res, comment.ID())
+	return res
+}
+
+// commentIDRe matches comment IDs, which consist of a prefix indicating the
+// type of the commented object, the object's ID and a suffix, like
+// f-<sha256>-<suffix>, u-<sha256>-<suffix>, d-<domain>-<suffix> or
+// i-<ip>-<suffix>.
+var commentIDRe = regexp.MustCompile(`^[a-z]-[0-9A-Za-z.:_-]+-[0-9A-Za-z]+$`)
+
+// commentDeleter is a Doer that deletes comments, receiving their IDs.
+type commentDeleter struct {
+	cli *utils.APIClient
+}
+
+func (d *commentDeleter) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	id := item.(string)
+	if !commentIDRe.MatchString(id) {
+		return utils.NewDoerError(id, fmt.Errorf("invalid comment ID %q", id))
+	}
+	_, err := d.cli.Delete(vt.URL("comments/%s", url.PathEscape(id)))
+	return apiResult(id, err)
+}
+
+var commentCmdHelp = `Read and write comments.
+
+Comments can be added to files, URLs, domains and IP addresses. The type of
+each object is detected automatically, use --type when it's ambiguous, for
+example for using URL identifiers instead of URLs.`
+
+// NewCommentCmd returns a new instance of the 'comment' command.
+func NewCommentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "comment",
+		Short: "Read and write comments",
+		Long:  commentCmdHelp,
+	}
+
+	cmd.AddCommand(NewCommentListCmd())
+	cmd.AddCommand(NewCommentAddCmd())
+	cmd.AddCommand(NewCommentDeleteCmd())
+	cmd.AddCommand(NewCommentSearchCmd())
+
+	return cmd
+}
+
+var commentListCmdExample = `  vt comment list 44d88612fea8a8f36de82e1278abb02f
+  vt comment list --limit 50 https://www.virustotal.com
+  vt comment list --type url 1db0ad7dbcec0676710ea0eaacd35d5e471d3e11944d53bcbd31f0cbd11bce31`
+
+// NewCommentListCmd returns a new instance of the 'comment list' command.
+func NewCommentListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Aliases: []string{"ls"},
+		Use:     "list [object]",
+		Short:   "List the comments for a file, URL, domain or IP address",
+		Example: commentListCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := objectPath(viper.GetString("type"), args[0])
+			if err != nil {
+				return err
+			}
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.PrintCollection(vt.URL("%s/comments", path))
+		},
+	}
+
+	addObjectTypeFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+	addLimitFlag(cmd.Flags())
+	addCursorFlag(cmd.Flags())
+
+	return cmd
+}
+
+var commentAddCmdHelp = `Add a comment to one or more objects.
+
+This command adds the comment specified with --text to one or more files,
+URLs, domains or IP addresses, and prints the ID of each comment added. Words
+starting with # in the comment, like #ourcampaign, are hashtags that can be
+searched with "vt comment search".
+
+If the command receives a single hypen (-) the objects are read from the
+standard input, one per line. Use --journal and --resume for continuing an
+interrupted run, as with "vt download".`
+
+var commentAddCmdExample = `  vt comment add --text 'Dropper seen in phishing #ourcampaign' 44d88612fea8a8f36de82e1278abb02f
+  cat iocs.txt | vt comment add --text '#ourcampaign infrastructure' -`
+
+// NewCommentAddCmd returns a new instance of the 'comment add' command.
+func NewCommentAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "add [object]...",
+		Short:   "Add a comment to files, URLs, domains or IP addresses",
+		Long:    commentAddCmdHelp,
+		Example: commentAddCmdExample,
+		Args:    minimumNArgsUnlessResume(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			objectType := viper.GetString("type")
+			if err := checkObjectType(objectType); err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			return runWithJournal(cmd, args, &commentAdder{
+				cli:        client,
+				objectType: objectType,
+				text:       viper.GetString("text"),
+			})
+		},
+	}
+
+	cmd.Flags().String("text", "", "comment's text (required)")
+	_ = cmd.MarkFlagRequired("text")
+	addObjectTypeFlag(cmd.Flags())
+	addThreadsFlag(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+
+	return cmd
+}
+
+var commentDeleteCmdExample = `  vt comment delete f-275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f-cd5c8f5d
+  cat comment_ids | vt comment delete -`
+
+// NewCommentDeleteCmd returns a new instance of the 'comment delete' command.
+func NewCommentDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Aliases: []string{"del", "rm"},
+		Use:     "delete [comment id]...",
+		Short:   "Delete comments",
+		Example: commentDeleteCmdExample,
+		Args:    minimumNArgsUnlessResume(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			return runWithJournal(cmd, args, &commentDeleter{cli: client})
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+
+	return cmd
+}
+
+var commentSearchCmdHelp = `Search for comments.
+
+This command receives a hashtag, like #ourcampaign, and returns the most
+recent comments that contain it. Other arguments are passed to the API as a
+comments filter as they are, like 'tag:ourcampaign'.`
+
+var commentSearchCmdExample = `  vt comment search '#ourcampaign'
+  vt comment search --limit 100 --include text,tags '#emotet'`
+
+// NewCommentSearchCmd returns a new instance of the 'comment search' command.
+func NewCommentSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "search [hashtag]",
+		Short:   "Search for comments with a hashtag",
+		Long:    commentSearchCmdHelp,
+		Example: commentSearchCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := args[0]
+			if strings.HasPrefix(filter, "#") {
+				filter = "tag:" + strings.TrimPrefix(filter, "#")
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			it, err := client.Iterator(vt.URL("comments"),
+				vt.IteratorFilter(filter),
+				vt.IteratorLimit(viper.GetInt("limit")),
+				vt.IteratorCursor(viper.GetString("cursor")))
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.PrintIterator(it)
+		},
+	}
+
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+	addLimitFlag(cmd.Flags())
+	addCursorFlag(cmd.Flags())
+
+	return cmd
+}
@@ -25,12 +25,21 @@ them. The data is returned in the same order as the domains appear in the
 command line.
 
 If the command receives a single hypen (-) the domains are read from the standard
-input, one per line.
+input, one per line. With --extract the domains are extracted from free text,
+like reports or logs, instead of being read one per line.
+
+With --input-format csv, tsv or jsonl the command receives a single file, or a
+hypen (-) for reading from the standard input, and the domains are read from the
+field specified with --input-field. For CSV and TSV the field is either a column
+number starting at 1 or a column name, for JSON Lines it is a path like src.ip.
+Use --with-input for including in the output the record from which each domain
+was read, as an "_input" field.
 `
 
 var domainCmdExample = `  vt domain virustotal.com
   vt domain virustotal.com google.com
-  cat list_of_domains | vt domain -`
+  cat list_of_domains | vt domain -
+  vt domain --input-format tsv --input-field 3 dns_queries.tsv`
 
 // NewDomainCmd returns a new instance of the 'domain' command.
 func NewDomainCmd() *cobra.Command {
@@ -43,14 +52,16 @@ func NewDomainCmd() *cobra.Command {
 		Args:    cobra.MinimumNArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			r, closeFn, err := inputReaderFromCmdArgs(args, utils.IOCDomain)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
 			p, err := NewPrinter(cmd)
 			if err != nil {
 				return err
 			}
-			return p.GetAndPrintObjects(
-				"domains/%s",
-				utils.StringReaderFromCmdArgs(args),
-				nil)
+			return p.GetAndPrintObjects("domains/%s", r, nil)
 		},
 	}
 
@@ -59,6 +70,8 @@ func NewDomainCmd() *cobra.Command {
 	addThreadsFlag(cmd.Flags())
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+	addInputFlags(cmd.Flags())
 
 	return cmd
 }
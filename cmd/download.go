@@ -14,20 +14,26 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/VirusTotal/vt-cli/utils"
 	vt "github.com/VirusTotal/vt-go"
-	"github.com/briandowns/spinner"
 	grab "github.com/cavaliergopher/grab/v3"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -36,50 +42,322 @@ type downloadCallback func(grabResp *grab.Response)
 type fileDownloader struct {
 	grab   *grab.Client
 	client *utils.APIClient
+	// store is the format in which downloaded samples are stored.
+	store *utils.SampleStore
+	// If skipExisting is true samples that were already downloaded are not
+	// downloaded again.
+	skipExisting bool
 }
 
-func newFileDownloader(client *utils.APIClient) fileDownloader {
+// newFileDownloader returns a fileDownloader configured with the --store and
+// --skip-existing flags.
+func newFileDownloader(client *utils.APIClient) (fileDownloader, error) {
+	store, err := utils.NewSampleStore(viper.GetString("store"))
+	if err != nil {
+		return fileDownloader{}, err
+	}
+	g := grab.NewClient()
+	// Use the same http.Client than the API client, so that downloads honor
+	// the proxy and timeout settings.
+	g.HTTPClient = client.HTTPClient
 	return fileDownloader{
-		grab:   grab.NewClient(),
-		client: client}
+		grab:         g,
+		client:       client,
+		store:        store,
+		skipExisting: viper.GetBool("skip-existing")}, nil
 }
 
-func (d *fileDownloader) DownloadFile(downloadURL, dstPath string, callback downloadCallback) error {
+// partPath returns the path of the file where a download is written until
+// it's completed and moved to dstPath.
+func partPath(dstPath string) string {
+	return filepath.Join(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".part")
+}
+
+// DownloadFile downloads the file at downloadURL into dstPath, calling the
+// callback periodically with the download's progress. The file is written to
+// a temporary file that is renamed to dstPath when the download completes, so
+// dstPath never contains an incomplete file. If the download is interrupted
+// the temporary file is kept, and the download is resumed from where it
+// stopped the next time. If dstPath is a directory the file is saved in it
+// with the name suggested by the server, and the incomplete file is removed
+// if ctx is cancelled.
+func (d *fileDownloader) DownloadFile(ctx context.Context, downloadURL, dstPath string, callback downloadCallback) error {
+	if utils.IsDir(dstPath) {
+		// The file's name is not known until the download starts, so it
+		// can't be written to a temporary file.
+		filename, err := d.fetch(ctx, downloadURL, dstPath, callback)
+		if err != nil && ctx.Err() != nil && filename != "" {
+			os.Remove(filename)
+		}
+		return err
+	}
+	part := partPath(dstPath)
+	if _, err := d.fetch(ctx, downloadURL, part, callback); err != nil {
+		return err
+	}
+	return os.Rename(part, dstPath)
+}
+
+// fetch downloads the file at downloadURL into dstPath, resuming the download
+// if dstPath contains a partial download, and returns the name of the file
+// where the download was written. The partial download is kept if ctx is
+// cancelled or the download fails.
+func (d *fileDownloader) fetch(ctx context.Context, downloadURL, dstPath string, callback downloadCallback) (string, error) {
 
 	req, err := grab.NewRequest(dstPath, downloadURL)
 	if err != nil {
-		return err
+		return "", err
 	}
 
+	req = req.WithContext(ctx)
+
 	req.HTTPRequest.Header.Add("x-apikey", d.client.APIKey)
 
 	resp := d.grab.Do(req)
 	t := time.NewTicker(500 * time.Millisecond)
 	defer t.Stop()
 
-Loop:
 	for {
 		select {
 		case <-t.C:
 			callback(resp)
 		case <-resp.Done:
 			if err := resp.Err(); err != nil {
-				return fmt.Errorf("download error: %+v", err)
+				if ctx.Err() != nil {
+					return resp.Filename, ctx.Err()
+				}
+				// The partial download is larger than the file, it can't be
+				// resumed.
+				if errors.Is(err, grab.ErrBadLength) {
+					os.Remove(resp.Filename)
+				}
+				utils.Debugf("download of %s into %s failed: %+v", downloadURL, dstPath, err)
+				return resp.Filename, fmt.Errorf("download error: %w", err)
 			}
 			callback(resp)
-			break Loop
+			return resp.Filename, nil
 		}
 	}
-	return nil
+}
+
+// mismatchError is returned when the content of a downloaded file doesn't
+// match the requested hash.
+type mismatchError struct {
+	hash   string
+	sha256 string
+}
+
+func (e *mismatchError) Error() string {
+	return fmt.Sprintf("downloaded content has SHA-256 %s, which doesn't match %s", e.sha256, e.hash)
+}
+
+// verifyDownload hashes the downloaded file at path and checks that it
+// matches hash. Files that don't match are removed. If hash is empty the file
+// is not verified, and no hashes are returned.
+func verifyDownload(path, hash string) (*utils.FileHashes, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	h, err := utils.HashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if hash != "" && !h.Matches(hash) {
+		os.Remove(path)
+		return nil, &mismatchError{hash: hash, sha256: h.SHA256}
+	}
+	return h, nil
+}
+
+// storeSample stores the downloaded file at path as dstPath, in the format
+// selected with --store, and returns the path where it was stored, which can
+// have an additional extension.
+func (d *fileDownloader) storeSample(path, dstPath string) (string, error) {
+	dstPath = d.store.Path(dstPath)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return "", err
+	}
+	return dstPath, d.store.Store(path, dstPath)
+}
+
+// existingSample returns the path where a sample that would be stored as
+// dstPath is, and true if --skip-existing was used and the sample is already
+// there with content that matches hash.
+func (d *fileDownloader) existingSample(dstPath, hash string) (string, bool) {
+	if !d.skipExisting || hash == "" {
+		return "", false
+	}
+	dstPath = d.store.Path(dstPath)
+	r, err := d.store.Open(dstPath)
+	if err != nil {
+		return "", false
+	}
+	defer r.Close()
+	h, err := utils.HashReader(r)
+	if err != nil || !h.Matches(hash) {
+		utils.Debugf("%s exists but doesn't match %s, downloading it again", dstPath, hash)
+		return "", false
+	}
+	return dstPath, true
 }
 
 // Standard downloader, it implements the Doer interface and downloads
-// individual files.
+// individual files. Files are downloaded to a temporary file and hashed, if
+// the content doesn't match the requested hash the file is deleted, if not,
+// it's renamed according to the name template and layout.
 type downloader struct {
 	fileDownloader
+	nameTemplate *utils.NameTemplate
+	// If sharded is true files are put in subdirectories named after the
+	// first characters of their SHA-256, like ab/cd/abcd...
+	sharded bool
+
+	mu sync.Mutex
+	// sums maps the paths of the downloaded files, relative to the output
+	// directory, to their SHA-256.
+	sums map[string]string
+}
+
+// defaultNameTemplate is the name template used when --name-template is not
+// specified.
+const defaultNameTemplate = "{sha256}"
+
+// newDownloader returns a new downloader configured with the --name-template
+// and --layout flags.
+func newDownloader(client *utils.APIClient) (*downloader, error) {
+	template := viper.GetString("name-template")
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	t, err := utils.ParseNameTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := newFileDownloader(client)
+	if err != nil {
+		return nil, err
+	}
+	d := &downloader{
+		fileDownloader: fd,
+		nameTemplate:   t,
+		sums:           make(map[string]string),
+	}
+	switch layout := viper.GetString("layout"); layout {
+	case "", "flat":
+	case "sharded":
+		d.sharded = true
+	default:
+		return nil, fmt.Errorf("invalid layout %q, use flat or sharded", layout)
+	}
+	return d, nil
 }
 
-func (d *downloader) Do(file interface{}, ds *utils.DoerState) string {
+// localNameFields are the fields in name templates whose values are known
+// without retrieving the file's object from VirusTotal.
+var localNameFields = map[string]bool{
+	"hash": true, "sha256": true, "sha1": true, "md5": true, "size": true,
+}
+
+// fileName returns the path, relative to the output directory, where a
+// downloaded file must be put. hash is the hash requested by the user and h
+// the hashes of the file's content. If the template uses fields other than
+// the hashes and the size they are taken from obj, which is retrieved if it's
+// nil. The object is returned for being reused.
+func (d *downloader) fileName(hash string, h *utils.FileHashes, obj *vt.Object) (string, *vt.Object, error) {
+	for _, field := range d.nameTemplate.Fields() {
+		if !localNameFields[field] && obj == nil {
+			var err error
+			if obj, err = d.client.GetObject(vt.URL("files/%s", h.SHA256)); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	name := d.nameTemplate.Expand(func(field string) string {
+		switch field {
+		case "hash":
+			return hash
+		case "sha256":
+			return h.SHA256
+		case "sha1":
+			return h.SHA1
+		case "md5":
+			return h.MD5
+		case "size":
+			return strconv.FormatInt(h.Size, 10)
+		}
+		if v, err := obj.Get(field); err == nil && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	})
+	if d.sharded {
+		name = filepath.Join(h.SHA256[0:2], h.SHA256[2:4], name)
+	}
+	return name, obj, nil
+}
+
+// existingFile returns the path of the file corresponding to the given hash
+// if it was already downloaded and --skip-existing was used. If hash is not a
+// SHA-256, or the name template uses fields other than the SHA-256, the file's
+// object is retrieved for knowing the name it would have. The object is
+// returned for being reused.
+func (d *downloader) existingFile(hash string) (string, *vt.Object, error) {
+	if !d.skipExisting {
+		return "", nil, nil
+	}
+	h := &utils.FileHashes{SHA256: strings.ToLower(hash)}
+	needsObject := len(hash) != 64
+	for _, field := range d.nameTemplate.Fields() {
+		needsObject = needsObject || (field != "hash" && field != "sha256")
+	}
+	var obj *vt.Object
+	if needsObject {
+		var err error
+		if obj, err = d.client.GetObject(vt.URL("files/%s", hash)); err != nil {
+			return "", nil, err
+		}
+		h.SHA256, _ = obj.GetString("sha256")
+		h.SHA1, _ = obj.GetString("sha1")
+		h.MD5, _ = obj.GetString("md5")
+		h.Size, _ = obj.GetInt64("size")
+		if len(h.SHA256) != 64 {
+			return "", obj, nil
+		}
+	}
+	name, obj, err := d.fileName(hash, h, obj)
+	if err != nil {
+		return "", obj, err
+	}
+	path, _ := d.existingSample(filepath.Join(viper.GetString("output"), name), hash)
+	return path, obj, nil
+}
+
+// downloadResult returns the result for a downloaded item.
+func downloadResult(item, dstPath string, size int64, err error) *utils.DoerResult {
+	if err != nil {
+		var mismatch *mismatchError
+		var status grab.StatusCodeError
+		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+			return &utils.DoerResult{Item: item, Status: "not found", Error: err}
+		} else if errors.As(err, &status) && status == http.StatusNotFound {
+			return &utils.DoerResult{Item: item, Status: "not found", Error: err}
+		} else if errors.As(err, &mismatch) {
+			return &utils.DoerResult{Item: item, Status: "mismatch", Error: err}
+		}
+		return utils.NewDoerError(item, err)
+	}
+	return utils.NewDoerResult(item, "ok").
+		With("path", dstPath).
+		With("bytes", size)
+}
+
+// skippedResult returns the result for an item that was not downloaded
+// because it was already stored at path.
+func skippedResult(item, path string) *utils.DoerResult {
+	return utils.NewDoerResult(item, "skipped").With("path", path)
+}
+
+func (d *downloader) Do(ctx context.Context, file interface{}, ds *utils.DoerState) *utils.DoerResult {
 
 	var hash string
 	if f, isObject := file.(*vt.Object); isObject {
@@ -88,15 +366,25 @@ func (d *downloader) Do(file interface{}, ds *utils.DoerState) string {
 		hash = file.(string)
 	}
 
+	existing, obj, err := d.existingFile(hash)
+	if err != nil {
+		return downloadResult(hash, "", 0, err)
+	} else if existing != "" {
+		return skippedResult(hash, existing)
+	}
+
 	ds.Progress = fmt.Sprintf("%s %4.1f%%", hash, 0.0)
 
 	// Get download URL
 	var downloadURL string
-	_, err := d.client.GetData(vt.URL("files/%s/download_url", hash), &downloadURL)
+	var size int64
+	_, err = d.client.GetData(vt.URL("files/%s/download_url", hash), &downloadURL)
 
+	output := viper.GetString("output")
+	part := partPath(filepath.Join(output, hash))
 	if err == nil {
-		dstPath := path.Join(viper.GetString("output"), hash)
-		err = d.DownloadFile(downloadURL, dstPath, func(resp *grab.Response) {
+		_, err = d.fetch(ctx, downloadURL, part, func(resp *grab.Response) {
+			size = resp.BytesComplete()
 			progress := 100 * resp.Progress()
 			if progress < 100 {
 				ds.Progress = fmt.Sprintf("%s %4.1f%% %6.1f KBi/s",
@@ -104,82 +392,358 @@ func (d *downloader) Do(file interface{}, ds *utils.DoerState) string {
 			}
 		})
 	}
+	if err != nil {
+		return downloadResult(hash, "", size, err)
+	}
 
-	msg := color.GreenString("ok")
+	ds.Progress = fmt.Sprintf("%s verifying...", hash)
+	h, err := verifyDownload(part, hash)
 	if err != nil {
-		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
-			msg = color.RedString("not found")
-		} else {
-			msg = color.RedString(err.Error())
+		return downloadResult(hash, "", size, err)
+	}
+	name, _, err := d.fileName(hash, h, obj)
+	var dstPath string
+	if err == nil {
+		dstPath, err = d.storeSample(part, filepath.Join(output, name))
+	}
+	if err != nil {
+		os.Remove(part)
+		return downloadResult(hash, "", size, err)
+	}
+
+	if err := d.addToManifest(name, dstPath, h.SHA256); err != nil {
+		return downloadResult(hash, dstPath, h.Size, err)
+	}
+	return downloadResult(hash, dstPath, h.Size, nil).With("sha256", h.SHA256)
+}
+
+// addToManifest records the SHA-256 of a sample stored at dstPath with the
+// given name, relative to the output directory, so that it's written to the
+// manifest. sha256 is the sample's SHA-256, but samples stored gzipped or
+// in a ZIP archive are hashed again, as the manifest must contain the hashes
+// of the files as they are in the output directory.
+func (d *downloader) addToManifest(name, dstPath, sha256 string) error {
+	if !viper.GetBool("manifest") {
+		return nil
+	}
+	if d.store.Format == utils.StoreGzip || d.store.Format == utils.StoreEncryptedZip {
+		h, err := utils.HashFile(dstPath)
+		if err != nil {
+			return err
 		}
+		sha256 = h.SHA256
 	}
+	d.mu.Lock()
+	d.sums[d.store.Path(name)] = sha256
+	d.mu.Unlock()
+	return nil
+}
 
-	return fmt.Sprintf("%s [%s]", hash, msg)
+// writeManifest adds the files downloaded so far to the SHA256SUMS file in the
+// output directory, if --manifest was used.
+func (d *downloader) writeManifest() error {
+	if !viper.GetBool("manifest") || len(d.sums) == 0 {
+		return nil
+	}
+	return utils.UpdateSHA256Sums(
+		filepath.Join(viper.GetString("output"), "SHA256SUMS"), d.sums)
 }
 
-// ZIP downloader, uses the API for creating ZIP files in the backend.
-type zipDownloader struct {
-	fileDownloader
+// zipBatch is a batch of hashes that are downloaded in a single ZIP file.
+type zipBatch struct {
+	// index is the batch's position, starting at 0.
+	index  int
+	hashes []string
 }
 
-func (z *zipDownloader) Download(hashes utils.StringReader, password string) error {
+// JournalItems implements utils.JournalItems, so that the hashes in the batch
+// are recorded individually in the journal.
+func (b zipBatch) JournalItems() []string {
+	return b.hashes
+}
 
-	spin := spinner.New(spinner.CharSets[6], 250*time.Millisecond)
-	spin.Color("green")
-	spin.Suffix = " creating ZIP..."
-	spin.Start()
-	defer spin.Stop()
+// zipFileStatus is the status of a requested hash after downloading the ZIP
+// file that should contain it.
+type zipFileStatus struct {
+	hash   string
+	status string
+	path   string
+}
 
-	hashList := make([]string, 0)
-	for hash, err := hashes.ReadString(); err == nil; hash, err = hashes.ReadString() {
-		hashList = append(hashList, hash)
+// ZIP downloader, it implements the Doer interface and downloads batches of
+// files in ZIP files created in the backend. The ZIP files are named after
+// --output and the batch number, and optionally extracted into the same layout
+// used by downloader.
+type zipDownloader struct {
+	*downloader
+	password string
+	// If extract is true the ZIP files are extracted and removed.
+	extract bool
+	// batches is the total number of batches.
+	batches int
+}
+
+// zipArchivePath returns the path of the ZIP file for the batch with the
+// given index. If output is a directory the ZIP files are put in it, if not,
+// output is the ZIP file's name, with the batch number added when there are
+// multiple batches.
+func (z *zipDownloader) zipArchivePath(index int) string {
+	output := viper.GetString("output")
+	if utils.IsDir(output) {
+		return filepath.Join(output, fmt.Sprintf("vt-download-%04d.zip", index+1))
+	}
+	if z.batches == 1 {
+		return output
 	}
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(output, ext), index+1, ext)
+}
 
+// createZip asks the backend to create a ZIP file with the given hashes and
+// waits until it's ready, returning the ZIP file's ID.
+func (z *zipDownloader) createZip(ctx context.Context, hashes []string, progress func(float64)) (string, error) {
 	req := struct {
 		Hashes   []string `json:"hashes,omitempty"`
 		Password string   `json:"password,omitempty"`
 	}{
-		Hashes:   hashList,
-		Password: password,
+		Hashes:   hashes,
+		Password: z.password,
 	}
 
 	resp, err := z.client.PostData(vt.URL("intelligence/zip_files"), &req)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var obj *vt.Object
 	if err := json.Unmarshal(resp.Data, &obj); err != nil {
-		return err
+		return "", err
 	}
 
 	for obj.MustGetString("status") != "finished" {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
 		obj, err = z.client.GetObject(vt.URL("intelligence/zip_files/%s", obj.ID()))
 		if err != nil {
-			return err
+			return "", err
 		}
 		switch status, _ := obj.GetString("status"); status {
 		case "error-starting":
-			return errors.New("Error starting ZIP file creation")
+			return "", errors.New("Error starting ZIP file creation")
 		case "error-creating":
-			return errors.New("Error creating ZIP file")
+			return "", errors.New("Error creating ZIP file")
 		case "timeout":
-			return errors.New("ZIP file creation is taking too long")
+			return "", errors.New("ZIP file creation is taking too long")
+		}
+		p, _ := obj.GetFloat64("progress")
+		progress(p)
+	}
+	return obj.ID(), nil
+}
+
+// readZip reads the files in the ZIP file at path and returns the status of
+// each of the requested hashes: "ok" if the ZIP file contains a file matching
+// the hash, or "missing" if not. If z.extract is true the files are stored in
+// the output directory like the ones downloaded individually.
+func (z *zipDownloader) readZip(path string, hashes []string) ([]*zipFileStatus, error) {
+	files := make([]*zipFileStatus, len(hashes))
+	byHash := make(map[string]*zipFileStatus, len(hashes))
+	for i, hash := range hashes {
+		files[i] = &zipFileStatus{hash: hash, status: "missing"}
+		byHash[strings.ToLower(hash)] = files[i]
+	}
+	output := filepath.Dir(path)
+	err := utils.ReadZip(path, z.password, func(name string, r io.Reader) error {
+		var tmp *os.File
+		if z.extract {
+			var err error
+			if tmp, err = os.CreateTemp(output, ".vt-*.part"); err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			defer tmp.Close()
+			r = io.TeeReader(r, tmp)
+		}
+		h, err := utils.HashReader(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		var f *zipFileStatus
+		for _, hash := range []string{h.SHA256, h.SHA1, h.MD5} {
+			if f = byHash[hash]; f != nil {
+				break
+			}
+		}
+		if f == nil {
+			utils.Debugf("%s contains %s, which was not requested", path, name)
+			return nil
+		}
+		f.status = "ok"
+		if !z.extract {
+			return nil
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		name, _, err = z.fileName(f.hash, h, nil)
+		if err != nil {
+			return err
+		}
+		if f.path, err = z.storeSample(tmp.Name(), filepath.Join(output, name)); err != nil {
+			return err
+		}
+		return z.addToManifest(name, f.path, h.SHA256)
+	})
+	return files, err
+}
+
+// zipResult returns the result for a batch downloaded into the ZIP file at
+// path, including the status of each hash. Hashes that are not "ok" are listed
+// below the ZIP file in the human-friendly output.
+func zipResult(path string, files []*zipFileStatus, err error) *utils.DoerResult {
+	var res *utils.DoerResult
+	missing := 0
+	list := make([]map[string]interface{}, len(files))
+	for i, f := range files {
+		list[i] = map[string]interface{}{"hash": f.hash, "status": f.status}
+		if f.path != "" {
+			list[i]["path"] = f.path
+		}
+		if f.status == "missing" {
+			missing++
+		}
+	}
+	if err != nil {
+		res = utils.NewDoerError(path, err)
+	} else if missing > 0 {
+		res = &utils.DoerResult{
+			Item:   path,
+			Status: "incomplete",
+			Error:  fmt.Errorf("%d of %d files missing", missing, len(files))}
+	} else {
+		res = utils.NewDoerResult(path, "ok")
+	}
+	res.With("files", list)
+	lines := []string{res.String()}
+	if err == nil {
+		for _, f := range files {
+			if f.status != "ok" {
+				lines = append(lines, fmt.Sprintf("  %s [%s]", f.hash, color.YellowString(f.status)))
+			}
+		}
+	}
+	res.Text = strings.Join(lines, "\n")
+	return res
+}
+
+func (z *zipDownloader) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	batch := item.(zipBatch)
+	path := z.zipArchivePath(batch.index)
+	prefix := fmt.Sprintf("%s (%d/%d)", filepath.Base(path), batch.index+1, z.batches)
+
+	// Files that were already extracted are not included in the ZIP file.
+	var skipped []*zipFileStatus
+	hashes := batch.hashes
+	if z.extract && z.skipExisting {
+		hashes = nil
+		for _, hash := range batch.hashes {
+			existing, _, err := z.existingFile(hash)
+			if err == nil && existing != "" {
+				skipped = append(skipped, &zipFileStatus{hash: hash, status: "skipped", path: existing})
+			} else {
+				hashes = append(hashes, hash)
+			}
+		}
+		if len(hashes) == 0 {
+			return zipResult(path, skipped, nil)
 		}
-		progress, _ := obj.GetFloat64("progress")
-		spin.Suffix = fmt.Sprintf(" creating ZIP... %2.0f%%", progress*100)
-		time.Sleep(2 * time.Second)
 	}
 
-	url := vt.URL("intelligence/zip_files/%s/download", obj.ID())
-	dstPath := viper.GetString("output")
+	ds.Progress = fmt.Sprintf("%s creating ZIP...", prefix)
+	id, err := z.createZip(ctx, hashes, func(progress float64) {
+		ds.Progress = fmt.Sprintf("%s creating ZIP... %2.0f%%", prefix, progress*100)
+	})
+	if err != nil {
+		return zipResult(path, nil, err)
+	}
 
-	err = z.DownloadFile(url.String(), dstPath, func(resp *grab.Response) {
-		spin.Suffix = fmt.Sprintf(
-			" downloading ZIP %4.1f%% %6.1f KBi/s",
-			resp.Progress()*100, resp.BytesPerSecond()/1024)
+	// A partial download of a previous ZIP file can't be resumed, as its
+	// content is not the same.
+	os.Remove(partPath(path))
+	url := vt.URL("intelligence/zip_files/%s/download", id)
+	err = z.DownloadFile(ctx, url.String(), path, func(resp *grab.Response) {
+		ds.Progress = fmt.Sprintf("%s downloading ZIP %4.1f%% %6.1f KBi/s",
+			prefix, resp.Progress()*100, resp.BytesPerSecond()/1024)
 	})
+	if err != nil {
+		return zipResult(path, nil, err)
+	}
+
+	ds.Progress = fmt.Sprintf("%s checking ZIP...", prefix)
+	files, err := z.readZip(path, hashes)
+	if err != nil {
+		return zipResult(path, nil, fmt.Errorf("reading ZIP file: %w", err))
+	}
+	if z.extract {
+		os.Remove(path)
+	}
+	return zipResult(path, append(skipped, files...), nil)
+}
+
+// runZipDownload implements "vt download --zip". Hashes are split in batches
+// of --zip-batch-size that are downloaded in parallel. The hashes in each batch
+// are recorded in journal.
+func runZipDownload(cmd *cobra.Command, client *utils.APIClient, journal *utils.Journal, hashes utils.StringReader) error {
+	var hashList []string
+	seen := make(map[string]bool)
+	for hash, err := hashes.ReadString(); err == nil; hash, err = hashes.ReadString() {
+		if !seen[strings.ToLower(hash)] {
+			seen[strings.ToLower(hash)] = true
+			hashList = append(hashList, hash)
+		}
+	}
+	size := viper.GetInt("zip-batch-size")
+	if size <= 0 {
+		return errors.New("--zip-batch-size must be greater than 0")
+	}
+	d, err := newDownloader(client)
+	if err != nil {
+		return err
+	}
+	z := &zipDownloader{
+		downloader: d,
+		password:   viper.GetString("zip-password"),
+		extract:    viper.GetBool("zip-extract"),
+		batches:    (len(hashList) + size - 1) / size,
+	}
+	if z.extract {
+		if err := os.MkdirAll(viper.GetString("output"), 0o755); err != nil {
+			return err
+		}
+	}
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
 
+	ch := make(chan interface{}, z.batches)
+	for i := 0; i < z.batches; i++ {
+		end := (i + 1) * size
+		if end > len(hashList) {
+			end = len(hashList)
+		}
+		ch <- zipBatch{index: i, hashes: hashList[i*size : end]}
+	}
+	close(ch)
+	err = doWithJournal(cmd, c, journal, func(ctx context.Context) {
+		c.DoWithItemsFromChannel(ctx, z, ch)
+	})
+	if manifestErr := z.writeManifest(); err == nil {
+		err = manifestErr
+	}
 	return err
 }
 
@@ -190,11 +754,61 @@ downloads the files from VirusTotal. For using this command you need an API
 key with access to VirusTotal Intelligence.
 
 If the command receives a single hypen (-) the hashes are read from the standard
-input, one per line.`
+input, one per line.
+
+Downloaded files are hashed and deleted if their content doesn't match the
+requested hash, in which case they are reported with status "mismatch". Files
+are named after their SHA-256, whatever the type of the requested hash, use
+--name-template for choosing a different name. Templates contain fields
+between braces, like '{sha256}.{type_extension}', which are replaced with the
+file's hashes ({sha256}, {sha1} and {md5}), its size ({size}), the hash as it
+was requested ({hash}), or any other attribute of the file in VirusTotal, like
+{type_tag}. Empty fields are removed together with the dot preceding them.
+With --layout sharded files are put in subdirectories named after the first
+two and the next two characters of their SHA-256, as in ab/cd/abcd... With
+--manifest the downloaded files are added to a SHA256SUMS file in the output
+directory, which can be checked with "sha256sum -c SHA256SUMS". The manifest
+contains the hashes of the files as they are stored, which for --store gzip
+and encrypted-zip are not the hashes of the samples.
+
+Files are first downloaded to a hidden .part file in the output directory,
+which is renamed once the download completes. If a download is interrupted the
+.part file is kept, and the next attempt continues from where it stopped. With
+--skip-existing files that are already in the output directory with the right
+content are not downloaded again. With --store the files are stored gzipped
+(.gz), in a ZIP archive encrypted with the password "infected" (.zip), or with
+a .vir extension that prevents them from being executed by accident
+(neutered), instead of as they are (plain).
+
+With --zip the files are downloaded in ZIP files created by VirusTotal, which
+are encrypted with --zip-password if specified. Hashes are split in batches of
+up to --zip-batch-size files, each of them downloaded in a different ZIP file,
+and the ZIP files are created in parallel, with the number of threads
+specified with --threads. If --output is a directory the ZIP files are named
+vt-download-0001.zip, vt-download-0002.zip, and so on, if not, --output is the
+name of the ZIP file, with the batch number added when there are multiple
+batches, as in samples-0001.zip. The hashes that are missing from a ZIP file
+are listed below it. With --zip-extract the ZIP files are extracted into the
+output directory, with the same names and layout used for files downloaded
+individually, and removed afterwards.
+
+Pressing Ctrl-C once stops starting new downloads and waits for the ones in
+progress, pressing it twice aborts them. In both cases a journal file with the
+completed, failed and pending hashes is written, use --resume with that file
+for retrying the failed hashes and continuing where the interrupted run
+stopped. When the hashes are read from the standard input, the ones that were
+not read yet are not recorded in the journal. The journal is also written if
+some downloads failed and --journal was used. With --zip all the hashes in a
+batch whose ZIP file is incomplete or failed are recorded as failed.`
 
 var downladCmdExample = `  vt download 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
   vt download 76cdb2bad9582d23c1f6f4d868218d6c 44d88612fea8a8f36de82e1278abb02f
-  cat list_of_hashes | vt download -`
+  cat list_of_hashes | vt download -
+  vt download --name-template '{sha256}.{type_extension}' --layout sharded --manifest -o repo/ - < hashes
+  vt download --skip-existing --store encrypted-zip -o samples/ - < hashes
+  vt download --zip --zip-password infected -o samples.zip - < hashes
+  vt download --zip --zip-extract --zip-batch-size 500 --layout sharded -o repo/ - < hashes
+  vt download --resume vt-20240101-120000.journal`
 
 // NewDownloadCmd returns a new instance of the 'download' command.
 func NewDownloadCmd() *cobra.Command {
@@ -205,14 +819,19 @@ func NewDownloadCmd() *cobra.Command {
 		Short:   "Download files",
 		Long:    downloadCmdHelp,
 		Example: downladCmdExample,
-		Args:    cobra.MinimumNArgs(1),
+		Args:    minimumNArgsUnlessResume(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var argReader utils.StringReader
-			if len(args) == 1 && args[0] == "-" {
-				argReader = utils.NewStringIOReader(os.Stdin)
-			} else {
-				argReader = utils.NewStringArrayReader(args)
+			journal, argReader, err := openJournal(cmd)
+			if err != nil {
+				return err
+			}
+			if argReader == nil {
+				if len(args) == 1 && args[0] == "-" {
+					argReader = utils.NewStringIOReader(os.Stdin)
+				} else {
+					argReader = utils.NewStringArrayReader(args)
+				}
 			}
 			client, err := NewAPIClient()
 			if err != nil {
@@ -221,13 +840,19 @@ func NewDownloadCmd() *cobra.Command {
 			re, _ := regexp.Compile(`^([[:xdigit:]]{64}|[[:xdigit:]]{40}|[[:xdigit:]]{32})$`)
 			hashes := utils.NewFilteredStringReader(argReader, re)
 			if viper.GetBool("zip") {
-				z := zipDownloader{newFileDownloader(client)}
-				err = z.Download(hashes, viper.GetString("zip-password"))
-			} else {
-				c := utils.NewCoordinator(viper.GetInt("threads"))
-				c.DoWithStringsFromReader(
-					&downloader{newFileDownloader(client)},
-					hashes)
+				return runZipDownload(cmd, client, journal, hashes)
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			d, err := newDownloader(client)
+			if err != nil {
+				return err
+			}
+			err = doWithJournalFromReader(cmd, c, journal, d, hashes)
+			if manifestErr := d.writeManifest(); err == nil {
+				err = manifestErr
 			}
 			return err
 		},
@@ -235,9 +860,37 @@ func NewDownloadCmd() *cobra.Command {
 
 	cmd.Flags().BoolP("zip", "z", false, "download in a ZIP file")
 	cmd.Flags().String("zip-password", "", "password for the ZIP file, used with --zip")
+	cmd.Flags().Int("zip-batch-size", 100, "maximum number of files in each ZIP file, used with --zip")
+	cmd.Flags().Bool("zip-extract", false, "extract the ZIP files into the output directory, used with --zip")
 
 	addThreadsFlag(cmd.Flags())
 	addOutputFlag(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+	addDownloadLayoutFlags(cmd.Flags())
+	addDownloadStoreFlags(cmd.Flags())
+
+	cmd.AddCommand(NewDownloadArtifactCmd())
 
 	return cmd
 }
+
+func addDownloadLayoutFlags(flags *pflag.FlagSet) {
+	flags.String(
+		"name-template", defaultNameTemplate,
+		"template for the names of downloaded files, like '{sha256}.{type_extension}'")
+	flags.String(
+		"layout", "flat",
+		"layout of the output directory: flat, or sharded for putting files in ab/cd/ subdirectories")
+	flags.Bool(
+		"manifest", false,
+		"add the downloaded files to a SHA256SUMS file in the output directory")
+}
+
+func addDownloadStoreFlags(flags *pflag.FlagSet) {
+	flags.Bool(
+		"skip-existing", false,
+		"don't download files that are already in the output directory with the right content")
+	flags.String(
+		"store", utils.StorePlain,
+		"how files are stored: plain, gzip, encrypted-zip (with password \"infected\") or neutered (with a .vir extension)")
+}
@@ -0,0 +1,184 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var enrichCmdHelp = `Enrich JSON Lines events with VirusTotal verdicts.
+
+This command reads events in JSON Lines format, one JSON object per line, looks
+up the indicators contained in the fields specified with --field and writes the
+events back in JSON Lines format, with a verdict block for each field added
+under the "vt" key (see --key). Events are processed as a stream, so the command
+can be used as a filter in log pipelines like Vector, Fluent Bit or Logstash.
+
+Each --field has the form <path>:<type>, where path can contain dots for
+referring to nested fields, and type is one of file, url, domain, ip or auto.
+With auto, or when the type is omitted, the type of each value is detected
+automatically. Fields containing arrays get a verdict for each item.
+
+Indicators are looked up in parallel and each one is looked up only once, the
+verdicts are cached and reused for later events. Events are processed in
+batches of up to --batch-size events, a batch is processed earlier if no new
+events arrive during --flush-interval. Lines that are not JSON objects are
+written as they are.
+
+The verdict block contains the items specified with --verdict. Valid items are
+malicious, suspicious, harmless, undetected, reputation, labels (tags, threat
+label and categories) and link. Any other item is taken as an attribute of the
+object, like last_analysis_date. Verdicts also include whether the indicator was
+found and the object's ID.`
+
+var enrichCmdExample = `  vt enrich --in events.jsonl --field dest_ip:ip --field file.sha256:file
+  tail -f events.jsonl | vt enrich --field url:url --verdict malicious,labels,link
+  vt enrich --in events.jsonl --out enriched.jsonl --field indicator:auto --key virustotal`
+
+// readLines reads lines from r and sends them through the returned channel,
+// which is closed when all lines were read. Errors are sent through errCh.
+func readLines(r io.Reader, errCh chan<- error) <-chan []byte {
+	ch := make(chan []byte)
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := make([]byte, len(scanner.Bytes()))
+			copy(line, scanner.Bytes())
+			ch <- line
+		}
+		errCh <- scanner.Err()
+	}()
+	return ch
+}
+
+// nextBatch reads up to size lines from ch. It returns earlier if no new
+// lines arrive during interval. The second value returned is false if ch was
+// closed.
+func nextBatch(ch <-chan []byte, size int, interval time.Duration) ([][]byte, bool) {
+	batch := make([][]byte, 0, size)
+	line, ok := <-ch
+	if !ok {
+		return batch, false
+	}
+	batch = append(batch, line)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for len(batch) < size {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, line)
+			timer.Reset(interval)
+		case <-timer.C:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+// NewEnrichCmd returns a new instance of the 'enrich' command.
+func NewEnrichCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "enrich",
+		Short:   "Enrich JSON Lines events with VirusTotal verdicts",
+		Long:    enrichCmdHelp,
+		Example: enrichCmdExample,
+		Args:    cobra.NoArgs,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specs := viper.GetStringSlice("field")
+			if len(specs) == 0 {
+				return errors.New("at least one --field must be specified")
+			}
+			fields := make([]utils.EnrichField, 0, len(specs))
+			for _, spec := range specs {
+				f, err := utils.ParseEnrichField(spec)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, f)
+			}
+			batchSize := viper.GetInt("batch-size")
+			if batchSize < 1 {
+				return errors.New("--batch-size must be greater than 0")
+			}
+
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+
+			in, closeIn, err := openTextFiles([]string{viper.GetString("in")})
+			if err != nil {
+				return err
+			}
+			defer closeIn()
+
+			out := os.Stdout
+			if filename := viper.GetString("out"); filename != "" && filename != "-" {
+				if out, err = os.Create(filename); err != nil {
+					return err
+				}
+				defer out.Close()
+			}
+			w := bufio.NewWriter(out)
+
+			e := utils.NewEnricher(client, fields)
+			e.Verdict = viper.GetStringSlice("verdict")
+			e.Key = viper.GetString("key")
+
+			errCh := make(chan error, 1)
+			lines := readLines(in, errCh)
+			for more := true; more; {
+				var batch [][]byte
+				batch, more = nextBatch(lines, batchSize, viper.GetDuration("flush-interval"))
+				for _, line := range e.EnrichJSONLines(cmd.Context(), batch) {
+					w.Write(line)
+					w.WriteByte('\n')
+				}
+				// Flush after each batch, so that the enriched events are
+				// available to the next stage of the pipeline as soon as
+				// possible.
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+			return <-errCh
+		},
+	}
+
+	cmd.Flags().String("in", "-", "JSON Lines file with the events, - for reading from stdin")
+	cmd.Flags().String("out", "-", "file where enriched events are written, - for writing to stdout")
+	cmd.Flags().StringSlice("field", nil, "field to enrich, as <path>:<type> (type is file, url, domain, ip or auto)")
+	cmd.Flags().StringSlice("verdict", utils.DefaultVerdict, "items included in each verdict")
+	cmd.Flags().String("key", "vt", "name of the field where verdicts are added")
+	cmd.Flags().Int("batch-size", 100, "maximum number of events processed together")
+	cmd.Flags().Duration("flush-interval", time.Second, "maximum time to wait for more events before processing a batch")
+
+	addThreadsFlag(cmd.Flags())
+
+	return cmd
+}
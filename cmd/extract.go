@@ -0,0 +1,101 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var extractCmdHelp = `Extract indicators of compromise from text.
+
+This command reads arbitrary text, like incident reports, email bodies or log
+files, and extracts the indicators of compromise found in it: MD5, SHA-1 and
+SHA-256 hashes, URLs, domains, IPv4 and IPv6 addresses and CVE identifiers.
+Defanged indicators like hxxp://example[.]com or 192.0.2(dot)1 are refanged.
+Private and reserved IP addresses and domains are ignored unless
+--keep-private is used. Each indicator is reported only once.
+
+The command receives one or more files, if no file is specified or the command
+receives a single hypen (-) the text is read from the standard input.
+
+Use --type for extracting only some types of indicators. Valid types are md5,
+sha1, sha256, url, domain, ipv4, ipv6 and cve. "hash" can be used for all the
+hash types and "ip" for both IPv4 and IPv6 addresses.
+
+The --extract flag in "vt file", "vt url" and "vt collection create" does the
+same extraction on their input.`
+
+var extractCmdExample = `  vt extract report.txt
+  vt extract --type hash,url -I report.txt
+  cat email.eml | vt extract -
+  vt extract -I --type hash report.txt | vt file -`
+
+// NewExtractCmd returns a new instance of the 'extract' command.
+func NewExtractCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "extract [file]...",
+		Short:   "Extract indicators of compromise from text",
+		Long:    extractCmdHelp,
+		Example: extractCmdExample,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			types, err := utils.ParseIOCTypes(viper.GetStringSlice("type"))
+			if err != nil {
+				return err
+			}
+			r, closeFn, err := openTextFiles(args)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			reader := utils.NewIOCReader(utils.NewStringIOReader(r), types...)
+			reader.KeepPrivate = viper.GetBool("keep-private")
+
+			iocs := make([]map[string]interface{}, 0)
+			for ioc, err := reader.ReadIOC(); err == nil; ioc, err = reader.ReadIOC() {
+				if viper.GetBool("identifiers-only") {
+					fmt.Println(ioc.Value)
+				} else {
+					iocs = append(iocs, map[string]interface{}{
+						"type":  string(ioc.Type),
+						"value": ioc.Value,
+					})
+				}
+			}
+			if len(iocs) == 0 {
+				return nil
+			}
+			// Printing doesn't require an API client, extracting indicators
+			// must work without an API key.
+			p, err := utils.NewPrinter(nil, cmd, &colorScheme)
+			if err != nil {
+				return err
+			}
+			return p.Print(iocs)
+		},
+	}
+
+	cmd.Flags().StringSliceP("type", "T", nil,
+		"types of indicators to extract (md5, sha1, sha256, hash, url, domain, ipv4, ipv6, ip, cve)")
+	cmd.Flags().Bool("keep-private", false,
+		"don't ignore private and reserved IP addresses and domains")
+	addIDOnlyFlag(cmd.Flags())
+
+	return cmd
+}
@@ -14,10 +14,20 @@
 package cmd
 
 import (
-	"github.com/VirusTotal/vt-cli/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"regexp"
+	"sort"
+	"sync"
 
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var fileCmdHelp = `Get information about one or more files.
@@ -27,13 +37,51 @@ information about the corresponding files. The information for each file appears
 in the same order as the hashes are passed to the command.
 
 If the command receives a single hypen (-) the hashes are read from the standard
-input, one per line.
+input, one per line. With --extract the hashes are extracted from free text,
+like reports or logs, instead of being read one per line.
+
+With --input-format csv, tsv or jsonl the command receives a single file, or a
+hypen (-) for reading from the standard input, and the hashes are read from the
+field specified with --input-field. For CSV and TSV the field is either a column
+number starting at 1 or a column name, for JSON Lines it is a path like src.ip.
+Use --with-input for including in the output the record from which each hash
+was read, as an "_input" field.
+
+With --local the command receives local files and directories instead of
+hashes. Directories are walked recursively, unless --recursive=false is used,
+every file is hashed locally and the hashes are looked up in VirusTotal without
+uploading anything. Files with the same content are looked up only once, unless
+--dedupe=false is used. Files that are unknown to VirusTotal
+are listed at the end, use --upload-unknown for uploading them for analysis.
+Use --include-files, --exclude-files, --max-size and --follow-symlinks for
+choosing which files are hashed.
+
+With --recurse-archives the files inside ZIP, TAR, tar.gz and gzip archives are
+hashed and looked up too, including archives inside other archives. They are
+reported with the archive's path, as in sample.zip!/payload/a.exe. Encrypted
+ZIP archives are opened with the password given with --archive-password, only
+the traditional ZIP encryption is supported, not AES. Files inside archives are
+never uploaded with --upload-unknown, only the archive itself. The password
+given with --password is sent with the files uploaded with --upload-unknown,
+for the protected files among them.
+
+With --fail-on the last_analysis_stats of each file is checked against a
+policy like 'malicious>=3 or suspicious>=5', a verdict line for each file is
+printed to the standard error, and the command exits with code 2 if any file
+matches the policy, with code 1 if some file couldn't be checked, for example
+because it was not found, and with code 0 otherwise.
 `
 
 var fileCmdExample = `  vt file 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
   vt file 76cdb2bad9582d23c1f6f4d868218d6c
   vt file 76cdb2bad9582d23c1f6f4d868218d6c 44d88612fea8a8f36de82e1278abb02f
-  cat list_of_hashes | vt file -`
+  cat list_of_hashes | vt file -
+  cat incident_report.txt | vt file --extract -
+  vt file --input-format csv --input-field sha256 --with-input edr_export.csv
+  vt file --local --include-files '*.exe' --include-files '*.dll' evidence/
+  vt file --local --upload-unknown --max-size 32MB downloads/
+  vt file --local --recurse-archives --archive-password infected triage.zip
+  vt file --fail-on 'malicious>=3 or suspicious>=5' - < release_hashes`
 
 // NewFileCmd returns a new instance of the 'file' command.
 func NewFileCmd() *cobra.Command {
@@ -45,23 +93,263 @@ func NewFileCmd() *cobra.Command {
 		Args:    cobra.MinimumNArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if viper.GetBool("local") {
+				if viper.GetString("fail-on") != "" {
+					return errors.New("--fail-on can't be used with --local")
+				}
+				return runLocalFileLookup(cmd, args)
+			}
+			for _, flag := range []string{"upload-unknown", "recurse-archives", "archive-password"} {
+				if cmd.Flags().Changed(flag) {
+					return fmt.Errorf("--%s requires --local", flag)
+				}
+			}
 			re, _ := regexp.Compile("[[:xdigit:]]{64}|[[:xdigit:]]{40}|[[:xdigit:]]{32}")
+			r, closeFn, err := inputReaderFromCmdArgs(args,
+				utils.IOCMD5, utils.IOCSHA1, utils.IOCSHA256)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+			gate, err := newGate()
+			if err != nil {
+				return err
+			}
 			p, err := NewPrinter(cmd)
 			if err != nil {
 				return err
 			}
-			return p.GetAndPrintObjects(
-				"files/%s",
-				utils.StringReaderFromCmdArgs(args),
-				re)
+			p.Gate = gate
+			if err := p.GetAndPrintObjects("files/%s", r, re); err != nil {
+				return err
+			}
+			return gateError(cmd, gate)
 		},
 	}
 
+	cmd.AddCommand(NewReanalyzeCmd("files"))
+	cmd.AddCommand(NewFileBehavioursCmd())
+	cmd.AddCommand(NewFileBehaviourSummaryCmd())
+	cmd.AddCommand(NewFileMitreCmd())
 	addRelationshipCmds(cmd, "files", "file", "[hash]")
 
 	addThreadsFlag(cmd.Flags())
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+	addInputFlags(cmd.Flags())
+	addWalkFlags(cmd.Flags(), true)
+	addDedupeFlag(cmd.Flags(), true)
+	addPasswordFlag(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
+
+	cmd.Flags().Bool(
+		"local", false,
+		"hash local files and directories and look up the hashes, without uploading the files")
+	cmd.Flags().Bool(
+		"upload-unknown", false,
+		"with --local, upload the files that are unknown to VirusTotal")
+	cmd.Flags().Bool(
+		"recurse-archives", false,
+		"with --local, hash the files inside ZIP, TAR, tar.gz and gzip archives")
+	cmd.Flags().String(
+		"archive-password", "",
+		"with --recurse-archives, password for opening encrypted ZIP archives")
 
 	return cmd
 }
+
+// localFileLookup is a Doer that hashes local files and looks up the hashes in
+// VirusTotal. Files with the same content as a file already processed are
+// reported as duplicates.
+type localFileLookup struct {
+	cli *utils.APIClient
+	// uploader is nil unless unknown files must be uploaded.
+	uploader *fileScanner
+
+	// If dedupe is true files with the same content as a file already
+	// processed are not looked up again.
+	dedupe bool
+
+	mu      sync.Mutex
+	seen    map[string]string
+	unknown []string
+}
+
+// archiveMember is a file contained in an archive, which is hashed while the
+// archive is read.
+type archiveMember struct {
+	path   string
+	hashes *utils.FileHashes
+}
+
+func (l *localFileLookup) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	var path string
+	var h *utils.FileHashes
+	if m, ok := item.(archiveMember); ok {
+		path, h = m.path, m.hashes
+	} else {
+		var err error
+		path = item.(string)
+		ds.Progress = fmt.Sprintf("%s hashing...", path)
+		if h, err = utils.HashFile(path); err != nil {
+			return utils.NewDoerError(path, err)
+		}
+	}
+
+	l.mu.Lock()
+	first, duplicate := l.seen[h.SHA256]
+	if !duplicate {
+		l.seen[h.SHA256] = path
+	}
+	duplicate = duplicate && l.dedupe
+	l.mu.Unlock()
+
+	if duplicate {
+		res := localFileResult(path, "duplicate", h).With("duplicate_of", first)
+		res.Text = fmt.Sprintf("%s %s [duplicate of %s]", path, h.SHA256, first)
+		return res
+	}
+
+	ds.Progress = fmt.Sprintf("%s looking up...", path)
+	obj, err := l.cli.GetObject(vt.URL("files/%s", h.SHA256))
+	if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+		// Files inside archives can't be uploaded, only the archive itself.
+		if _, isMember := item.(archiveMember); l.uploader != nil && !isMember {
+			res := l.uploader.Do(ctx, path, ds)
+			if res.Error == nil {
+				res.Status = "uploaded"
+			}
+			return withHashes(res, h)
+		}
+		l.mu.Lock()
+		l.unknown = append(l.unknown, path)
+		l.mu.Unlock()
+		res := localFileResult(path, "unknown", h)
+		res.Text = fmt.Sprintf("%s %s [%s]", path, h.SHA256, color.YellowString("unknown"))
+		return res
+	} else if err != nil {
+		return withHashes(utils.NewDoerError(path, err), h)
+	}
+
+	res := localFileResult(path, "known", h)
+	verdict := utils.Verdict(obj, []string{"malicious", "suspicious"})
+	malicious, suspicious := verdict["malicious"].(int64), verdict["suspicious"].(int64)
+	res.With("malicious", malicious).With("suspicious", suspicious)
+	status := fmt.Sprintf("known, %d malicious, %d suspicious", malicious, suspicious)
+	if malicious > 0 {
+		status = color.RedString(status)
+	} else {
+		status = color.GreenString(status)
+	}
+	res.Text = fmt.Sprintf("%s %s [%s]", path, h.SHA256, status)
+	return res
+}
+
+// localFileResult returns a result for a local file, including its hashes.
+func localFileResult(path, status string, h *utils.FileHashes) *utils.DoerResult {
+	return withHashes(utils.NewDoerResult(path, status), h)
+}
+
+// withHashes adds the hashes in h to the result and returns the result itself.
+func withHashes(res *utils.DoerResult, h *utils.FileHashes) *utils.DoerResult {
+	return res.
+		With("sha256", h.SHA256).
+		With("sha1", h.SHA1).
+		With("md5", h.MD5).
+		With("size", h.Size)
+}
+
+// runLocalFileLookup implements "vt file --local".
+func runLocalFileLookup(cmd *cobra.Command, args []string) error {
+	w, err := newWalker()
+	if err != nil {
+		return err
+	}
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	l := &localFileLookup{
+		cli:    client,
+		dedupe: viper.GetBool("dedupe"),
+		seen:   make(map[string]string),
+	}
+	if viper.GetBool("upload-unknown") {
+		l.uploader = &fileScanner{
+			scanner:  client.NewFileScanner(),
+			password: viper.GetString("password"),
+			cli:      client}
+	}
+	recurseArchives := viper.GetBool("recurse-archives")
+	password := viper.GetString("archive-password")
+	if password != "" && !recurseArchives {
+		return errors.New("--archive-password requires --recurse-archives")
+	}
+
+	ctx, stop := utils.WithInterrupt(cmd.Context())
+	defer stop()
+
+	var paths []string
+	r := utils.StringReaderFromCmdArgs(args)
+	for s, err := r.ReadString(); s != "" || err == nil; s, err = r.ReadString() {
+		paths = append(paths, s)
+	}
+	ch := make(chan interface{})
+	walkErr := make(chan error, 1)
+	draining := utils.Draining(ctx)
+	send := func(item interface{}) error {
+		select {
+		case ch <- item:
+			return nil
+		case <-draining:
+			return fs.SkipAll
+		}
+	}
+	go func() {
+		walkErr <- w.Walk(paths, func(path string, info fs.FileInfo) error {
+			if err := send(path); err != nil || !recurseArchives {
+				return err
+			}
+			err := utils.WalkArchive(path, password, func(name string, r io.Reader) error {
+				h, err := utils.HashReader(r)
+				if err != nil {
+					return err
+				}
+				if w.MaxSize > 0 && h.Size > w.MaxSize {
+					utils.Debugf("skipping %s: larger than %d bytes", name, w.MaxSize)
+					return nil
+				}
+				return send(archiveMember{path: name, hashes: h})
+			})
+			if err != nil && !errors.Is(err, fs.SkipAll) {
+				utils.Warnf("skipping contents of %s: %v", path, err)
+				return nil
+			}
+			return err
+		})
+		close(ch)
+	}()
+	c.DoWithItemsFromChannel(ctx, l, ch)
+	if err := <-walkErr; err != nil {
+		return err
+	}
+	if utils.Interrupted(ctx) {
+		cmd.SilenceUsage = true
+		return errors.New("interrupted")
+	}
+
+	if len(l.unknown) > 0 {
+		sort.Strings(l.unknown)
+		utils.Warnf("%d files are unknown to VirusTotal, use --upload-unknown for uploading them:",
+			len(l.unknown))
+		for _, path := range l.unknown {
+			utils.Warnf("  %s", path)
+		}
+	}
+	return nil
+}
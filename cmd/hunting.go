@@ -62,7 +62,7 @@ func NewHuntingNotificationDeleteCmd() *cobra.Command {
 					go func(notificationID string) {
 						url := vt.URL("intelligence/hunting_notifications/%s", notificationID)
 						if _, err := client.Delete(url); err != nil {
-							fmt.Fprintf(os.Stderr, "%v\n", err)
+							utils.Errorf("%v", err)
 						}
 						wg.Done()
 					}(arg)
@@ -288,7 +288,7 @@ func NewHuntingRulesetDeleteCmd() *cobra.Command {
 					go func(rulesetID string) {
 						url := vt.URL("intelligence/hunting_rulesets/%s", rulesetID)
 						if _, err := client.Delete(url); err != nil {
-							fmt.Fprintf(os.Stderr, "%v\n", err)
+							utils.Errorf("%v", err)
 						}
 						wg.Done()
 					}(arg)
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path"
 
+	"github.com/VirusTotal/vt-cli/utils"
 	vt "github.com/VirusTotal/vt-go"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -60,23 +61,29 @@ func NewInitCmd() *cobra.Command {
 				fmt.Scanln(&apiKey)
 			}
 
-			client := vt.NewClient(apiKey)
+			httpClient, err := utils.NewHTTPClient()
+			if err != nil {
+				utils.Errorf("%v", err)
+				os.Exit(1)
+			}
+
+			client := vt.NewClient(apiKey, vt.WithHTTPClient(httpClient))
 
 			metadata, err := client.GetMetadata()
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 
 			dir, err := homedir.Dir()
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 
 			relCacheFile, err := os.Create(path.Join(dir, ".vt.relationships.cache"))
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 			defer relCacheFile.Close()
@@ -84,21 +91,21 @@ func NewInitCmd() *cobra.Command {
 			enc := gob.NewEncoder(relCacheFile)
 
 			if err := enc.Encode(metadata.Relationships); err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 
 			configFilePath := path.Join(dir, ".vt.toml")
 			configFile, err := os.Create(configFilePath)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 			defer configFile.Close()
 
 			_, err = fmt.Fprintf(configFile, "apikey=\"%s\"\n", apiKey)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
+				utils.Errorf("%v", err)
 				os.Exit(1)
 			}
 
@@ -14,10 +14,14 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/VirusTotal/vt-cli/utils"
-	"regexp"
+	vt "github.com/VirusTotal/vt-go"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var ipCmdHelp = `Get information about one or more IP addresses.
@@ -26,12 +30,97 @@ This command receives one or more IP addresses and returns information about
 them. The information for each IP address is returned in the same order as the
 IP addresses are passed to the command.
 
+Both IPv4 and IPv6 addresses are accepted, as well as CIDR blocks (e.g:
+203.0.113.0/28) and ranges (e.g: 203.0.113.1-203.0.113.20 or 203.0.113.1-20),
+which are expanded into the individual addresses they contain. A block or range
+can't contain more than --max-expand addresses.
+
+With --aggregate the information about the addresses is summarized per network
+prefix or per autonomous system, which is useful when investigating large
+ranges. Use "asn" for grouping by autonomous system or a prefix length like
+"/24" for grouping by network. IPv6 addresses are grouped per /64, unless a
+second prefix length is given, as in "/24,/48".
+
 If the command receives a single hypen (-) the IP addresses will be read from
-the standard input, one per line.`
+the standard input, one per line. With --extract the IP addresses are extracted
+from free text, like reports or logs, instead of being read one per line.
+
+With --input-format csv, tsv or jsonl the command receives a single file, or a
+hypen (-) for reading from the standard input, and the IP addresses are read
+from the field specified with --input-field. For CSV and TSV the field is either
+a column number starting at 1 or a column name, for JSON Lines it is a path like
+src.ip. Use --with-input for including in the output the record from which each
+IP address was read, as an "_input" field.`
 
 var ipCmdExample = `  vt ip 8.8.8.8
   vt ip 8.8.8.8 8.8.4.4
-  cat list_of_ips | vt ip -`
+  vt ip 2001:4860:4860::8888
+  vt ip 203.0.113.0/28
+  vt ip 203.0.113.0/22 --max-expand 1024 --aggregate /24
+  vt ip 198.51.100.1-198.51.100.200 --aggregate asn
+  cat list_of_ips | vt ip -
+  vt ip --input-format jsonl --input-field dest_ip --with-input firewall.jsonl`
+
+// readIPs returns the IP addresses read from r, expanding CIDR blocks and
+// ranges, and the records from which they were read.
+func readIPs(r utils.StringReader) ([]string, []interface{}, error) {
+	max := viper.GetInt("max-expand")
+	if max < 1 || max > utils.MaxIPExpand {
+		return nil, nil, fmt.Errorf("--max-expand must be between 1 and %d", utils.MaxIPExpand)
+	}
+	ir := utils.NewIPRangeReader(r, max)
+	ips := make([]string, 0)
+	records := make([]interface{}, 0)
+	for {
+		s, err := ir.ReadString()
+		if err == io.EOF {
+			return ips, records, nil
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("%v, use --max-expand for raising the limit", err)
+		}
+		ips = append(ips, s)
+		records = append(records, ir.Record())
+	}
+}
+
+// aggregateIPs retrieves the given IP addresses and prints a summary for each
+// group of addresses.
+func aggregateIPs(cmd *cobra.Command, ips []string, agg utils.IPAggregation) error {
+	client, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	p, err := utils.NewPrinter(client, cmd, &colorScheme)
+	if err != nil {
+		return err
+	}
+
+	objectsCh := make(chan *vt.Object)
+	errorsCh := make(chan error, len(ips))
+
+	go client.RetrieveObjects(cmd.Context(), "ip_addresses/%s", ips, objectsCh, errorsCh)
+
+	var objs []*vt.Object
+	for obj := range objectsCh {
+		objs = append(objs, obj)
+	}
+	for err := range errorsCh {
+		utils.Errorf("%v", err)
+	}
+
+	groups := utils.AggregateIPs(objs, agg)
+	if viper.IsSet("include") || viper.IsSet("exclude") {
+		for i, g := range groups {
+			groups[i] = utils.FilterMap(g,
+				viper.GetStringSlice("include"),
+				viper.GetStringSlice("exclude"))
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return p.Print(groups)
+}
 
 // NewIPCmd returns a new instance of the 'ip' command.
 func NewIPCmd() *cobra.Command {
@@ -43,15 +132,33 @@ func NewIPCmd() *cobra.Command {
 		Args:    cobra.MinimumNArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			re, _ := regexp.Compile(`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}`)
+			var agg utils.IPAggregation
+			if viper.IsSet("aggregate") {
+				var err error
+				if agg, err = utils.ParseIPAggregation(viper.GetString("aggregate")); err != nil {
+					return err
+				}
+			}
+			r, closeFn, err := inputReaderFromCmdArgs(args, utils.IOCIPv4, utils.IOCIPv6)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+			ips, records, err := readIPs(r)
+			if err != nil {
+				return err
+			}
+			if viper.IsSet("aggregate") {
+				return aggregateIPs(cmd, ips, agg)
+			}
 			p, err := NewPrinter(cmd)
 			if err != nil {
 				return err
 			}
 			return p.GetAndPrintObjects(
 				"ip_addresses/%s",
-				utils.StringReaderFromCmdArgs(args),
-				re)
+				utils.NewRecordArrayReader(ips, records),
+				nil)
 		},
 	}
 
@@ -60,6 +167,13 @@ func NewIPCmd() *cobra.Command {
 	addThreadsFlag(cmd.Flags())
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+	addInputFlags(cmd.Flags())
+
+	cmd.Flags().Int("max-expand", 256,
+		fmt.Sprintf("maximum number of addresses a CIDR block or range can be expanded into (up to %d)", utils.MaxIPExpand))
+	cmd.Flags().String("aggregate", "",
+		"summarize results per network prefix (e.g: /24) or per autonomous system (asn)")
 
 	return cmd
 }
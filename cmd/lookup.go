@@ -0,0 +1,160 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var lookupCmdHelp = `Get information about indicators of any type.
+
+This command receives one or more indicators of any type and returns information
+about them, detecting the type of each indicator and using the same endpoint
+than the corresponding command would use. Accepted indicators are file hashes
+(SHA-256, SHA-1 or MD5), URLs, domains, IPv4 and IPv6 addresses and analysis
+IDs. The information for each indicator is returned in the same order as the
+indicators are passed to the command, and the "_type" field tells the type of
+each object.
+
+Defanged indicators like hxxp://example[.]com are refanged. Indicators whose
+type can't be determined are ignored with a warning. SHA-256 hashes are always
+looked up as files, use "vt url" for looking up URL identifiers.
+
+If the command receives a single hypen (-) the indicators are read from the
+standard input, one per line. With --extract the indicators are extracted from
+free text, like reports or logs, instead of being read one per line.`
+
+var lookupCmdExample = `  vt lookup 8.8.8.8 virustotal.com 44d88612fea8a8f36de82e1278abb02f
+  vt lookup https://www.virustotal.com
+  cat list_of_iocs | vt lookup -
+  cat incident_report.txt | vt lookup --extract -`
+
+// Types of the indicators extracted from free text with --extract, these are
+// the types that can be looked up.
+var lookupIOCTypes = []utils.IOCType{
+	utils.IOCMD5, utils.IOCSHA1, utils.IOCSHA256, utils.IOCURL,
+	utils.IOCDomain, utils.IOCIPv4, utils.IOCIPv6}
+
+// lookupPath returns the API path for the object corresponding to the given
+// indicator. The second value returned is false if the indicator is not
+// supported.
+func lookupPath(ioc string) (string, bool) {
+	t, ok := utils.DetectIOCType(ioc)
+	if !ok {
+		return "", false
+	}
+	return utils.ObjectPath(t, ioc)
+}
+
+// objectTypes are the values accepted by --type in commands that work with
+// objects of multiple types, like "vt comment" and "vt vote", and the types of
+// the indicators that identify objects of each type.
+var objectTypes = map[string][]utils.IOCType{
+	"file":   {utils.IOCMD5, utils.IOCSHA1, utils.IOCSHA256},
+	"url":    {utils.IOCURL},
+	"domain": {utils.IOCDomain},
+	"ip":     {utils.IOCIPv4, utils.IOCIPv6},
+}
+
+// objectPath returns the API path for the object identified by s, which is a
+// file hash, URL, domain or IP address. If objectType is empty the type is
+// detected like "vt lookup" does, if not it's one of the keys in objectTypes
+// and s must be an indicator of that type. URLs are encoded like "vt url"
+// does, so URL identifiers can be used with objectType "url".
+func objectPath(objectType, s string) (string, error) {
+	if objectType == "" {
+		if path, ok := lookupPath(utils.Refang(s)); ok && !strings.HasPrefix(path, "analyses/") {
+			return path, nil
+		}
+		return "", fmt.Errorf("can't determine the type of %q, use --type", s)
+	}
+	if err := checkObjectType(objectType); err != nil {
+		return "", err
+	}
+	if objectType == "url" {
+		// URLs are encoded with base64, which makes them safe to use in
+		// paths, unless they are already URL identifiers.
+		if t, _ := utils.DetectIOCType(s); t == utils.IOCSHA256 {
+			return "urls/" + strings.ToLower(s), nil
+		}
+		path, _ := utils.ObjectPath(utils.IOCURL, s)
+		return path, nil
+	}
+	ioc := utils.Refang(s)
+	if t, ok := utils.DetectIOCType(ioc); ok {
+		for _, allowed := range objectTypes[objectType] {
+			if t == allowed {
+				path, _ := utils.ObjectPath(t, ioc)
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%q is not a valid %s", s, objectType)
+}
+
+// checkObjectType returns an error if objectType is not empty and it's not
+// one of the keys in objectTypes.
+func checkObjectType(objectType string) error {
+	if _, ok := objectTypes[objectType]; objectType != "" && !ok {
+		return fmt.Errorf("invalid type %q, use file, url, domain or ip", objectType)
+	}
+	return nil
+}
+
+// addObjectTypeFlag adds the --type flag to commands that use objectPath.
+func addObjectTypeFlag(flags *pflag.FlagSet) {
+	flags.String(
+		"type", "",
+		"type of the objects: file, url, domain or ip (detected automatically by default)")
+}
+
+// NewLookupCmd returns a new instance of the 'lookup' command.
+func NewLookupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "lookup [ioc]...",
+		Short:   "Get information about indicators of any type",
+		Long:    lookupCmdHelp,
+		Example: lookupCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := stringReaderFromCmdArgs(args, lookupIOCTypes...)
+			paths := make([]string, 0)
+			for s, err := r.ReadString(); s != "" || err == nil; s, err = r.ReadString() {
+				if path, ok := lookupPath(utils.Refang(s)); ok {
+					paths = append(paths, path)
+				} else {
+					utils.Warnf("ignoring %q: unknown indicator type", s)
+				}
+			}
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.GetAndPrintObjects("%s", utils.NewStringArrayReader(paths), nil)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+
+	return cmd
+}
@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -27,7 +28,6 @@ import (
 	"github.com/VirusTotal/vt-cli/utils"
 	vt "github.com/VirusTotal/vt-go"
 	grab "github.com/cavaliergopher/grab/v3"
-	"github.com/fatih/color"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -69,7 +69,7 @@ type monitorDownloader struct {
 	fileDownloader
 }
 
-func (d *monitorDownloader) Do(file interface{}, ds *utils.DoerState) string {
+func (d *monitorDownloader) Do(ctx context.Context, file interface{}, ds *utils.DoerState) *utils.DoerResult {
 	var monitorItemID string
 	if f, isObject := file.(*vt.Object); isObject {
 		monitorItemID = f.ID()
@@ -82,12 +82,12 @@ func (d *monitorDownloader) Do(file interface{}, ds *utils.DoerState) string {
 	var obj *vt.Object
 	obj, err := d.client.GetObject(vt.URL("monitor/items/%s", monitorItemID))
 	if err != nil {
-		return fmt.Sprintf("%s [%s]", monitorItemID, color.RedString(err.Error()))
+		return utils.NewDoerError(monitorItemID, err)
 	}
 
 	monitorPath, err := obj.GetString("path")
 	if err != nil {
-		return fmt.Sprintf("%s [%s]", monitorItemID, color.RedString(err.Error()))
+		return utils.NewDoerError(monitorItemID, err)
 	}
 
 	monitorPath = strings.TrimPrefix(monitorPath, "/")
@@ -95,13 +95,23 @@ func (d *monitorDownloader) Do(file interface{}, ds *utils.DoerState) string {
 	// From now progress shows the path instead of monitorItemID
 	ds.Progress = fmt.Sprintf("%s %4.1f%%", monitorPath, 0.0)
 
+	// Items are verified against their SHA-256, if available.
+	sha256, _ := obj.GetString("sha256")
+	dstPath := path.Join(viper.GetString("output"), monitorPath)
+	if existing, ok := d.existingSample(dstPath, sha256); ok {
+		return skippedResult(monitorPath, existing).
+			With("monitor_id", monitorItemID)
+	}
+
 	// Get download URL
 	var downloadURL string
+	var size int64
 	_, err = d.client.GetData(vt.URL("monitor/items/%s/download_url", monitorItemID), &downloadURL)
 
+	part := partPath(dstPath)
 	if err == nil {
-		dstPath := path.Join(viper.GetString("output"), monitorPath)
-		err = d.DownloadFile(downloadURL, dstPath, func(resp *grab.Response) {
+		_, err = d.fetch(ctx, downloadURL, part, func(resp *grab.Response) {
+			size = resp.BytesComplete()
 			progress := 100 * resp.Progress()
 			if progress < 100 {
 				ds.Progress = fmt.Sprintf("%s %4.1f%% %6.1f KBi/s",
@@ -109,22 +119,29 @@ func (d *monitorDownloader) Do(file interface{}, ds *utils.DoerState) string {
 			}
 		})
 	}
-
-	msg := color.GreenString("ok")
-	if err != nil {
-		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
-			msg = color.RedString("not found")
-		} else {
-			msg = color.RedString(err.Error())
-		}
+	if err == nil {
+		_, err = verifyDownload(part, sha256)
+	}
+	if err == nil {
+		dstPath, err = d.storeSample(part, dstPath)
 	}
 
-	return fmt.Sprintf("%s [%s]", monitorPath, msg)
+	// Results are shown with the item's path, which is more meaningful than
+	// its ID for users.
+	return downloadResult(monitorPath, dstPath, size, err).
+		With("monitor_id", monitorItemID)
 }
 
 var monitorItemsDownloadCmdHelp = `Download files from your account.
 
-This command download files in your monitor account using their MonitorItemID.`
+This command download files in your monitor account using their MonitorItemID.
+
+Files are downloaded to a temporary file that is renamed once the download is
+complete and verified against the file's SHA-256. Interrupted downloads are
+resumed the next time. With --skip-existing files that were already
+downloaded are not downloaded again, and with --store files are stored
+compressed with gzip, in a ZIP archive encrypted with the password "infected",
+or with a .vir extension.`
 
 var monitorItemsDownloadCmdExample = `  vt monitor download "MonitorItemID"
   vt monitor download "MonitorItemID1" "MonitorItemID2" ...
@@ -154,9 +171,16 @@ func NewMonitorItemsDownloadCmd() *cobra.Command {
 			re, _ := regexp.Compile(base64RegExp)
 			monitorItemIDs := utils.NewFilteredStringReader(argReader, re)
 
-			c := utils.NewCoordinator(viper.GetInt("threads"))
-			c.DoWithStringsFromReader(
-				&monitorDownloader{fileDownloader: newFileDownloader(client)},
+			fd, err := newFileDownloader(client)
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			c.DoWithStringsFromReader(cmd.Context(),
+				&monitorDownloader{fileDownloader: fd},
 				monitorItemIDs)
 			return err
 		},
@@ -164,6 +188,7 @@ func NewMonitorItemsDownloadCmd() *cobra.Command {
 
 	addThreadsFlag(cmd.Flags())
 	addOutputFlag(cmd.Flags())
+	addDownloadStoreFlags(cmd.Flags())
 	return cmd
 }
 
@@ -246,7 +271,7 @@ func NewMonitorItemsDeleteDetailsCmd() *cobra.Command {
 					obj.Set("details", nil)
 
 					if err := client.PatchObject(url, obj); err != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", err)
+						utils.Errorf("%v", err)
 					}
 					waitGroup.Done()
 				}(arg)
@@ -285,7 +310,7 @@ func NewMonitorItemsDeleteCmd() *cobra.Command {
 				go func(monitorItemID string) {
 					url := vt.URL("monitor/items/%s", monitorItemID)
 					if _, err := client.Delete(url); err != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", err)
+						utils.Errorf("%v", err)
 					}
 					waitGroup.Done()
 				}(arg)
@@ -309,7 +334,7 @@ type uploadParams struct {
 	remotePath string
 }
 
-func (s *monitorFileUpload) Do(file interface{}, ds *utils.DoerState) string {
+func (s *monitorFileUpload) Do(ctx context.Context, file interface{}, ds *utils.DoerState) *utils.DoerResult {
 	params := file.(uploadParams)
 
 	progressCh := make(chan float32)
@@ -327,16 +352,20 @@ func (s *monitorFileUpload) Do(file interface{}, ds *utils.DoerState) string {
 
 	f, err := os.Open(params.filePath)
 	if err != nil {
-		return fmt.Sprintf("%s", err)
+		return utils.NewDoerError(params.filePath, err)
 	}
 	defer f.Close()
 
 	item, err := s.uploader.Upload(f, params.remotePath, progressCh)
 	if err != nil {
-		return fmt.Sprintf("%s", err)
+		return utils.NewDoerError(params.filePath, err)
 	}
 
-	return fmt.Sprintf("%s %s", params.filePath, item.ID())
+	res := utils.NewDoerResult(params.filePath, "ok").
+		With("remote_path", params.remotePath).
+		With("monitor_id", item.ID())
+	res.Text = fmt.Sprintf("%s %s", params.filePath, item.ID())
+	return res
 }
 
 // runMonitorItemUpload exectutes the items upload, requesting verification from user
@@ -355,20 +384,21 @@ func runMonitorItemUpload(cmd *cobra.Command, args []string) error {
 	switch mode := pathStat.Mode(); {
 	case mode.IsDir():
 		// Upload tree to remote
-		localPathClean := strings.TrimRight(localPath, string(os.PathSeparator)) + string(os.PathSeparator)
 		remotePathClean := strings.TrimRight(remotePath, "/") + "/"
 
-		filesParams := make([]uploadParams, 0)
-		filepath.Walk(
-			localPathClean, func(path string, info os.FileInfo, err error) error {
-				if !info.Mode().IsRegular() {
-					return nil
-				}
-				relativePath := strings.SplitN(path, localPathClean, 2)[1]
-				remoteAbsoluteFilename := remotePathClean + relativePath
-				filesParams = append(filesParams, uploadParams{path, remoteAbsoluteFilename})
-				return nil
-			})
+		files, err := collectFiles([]string{localPath})
+		if err != nil {
+			return err
+		}
+		filesParams := make([]uploadParams, 0, len(files.Paths))
+		for _, path := range files.Paths {
+			relativePath, err := filepath.Rel(localPath, path)
+			if err != nil {
+				return err
+			}
+			remoteAbsoluteFilename := remotePathClean + filepath.ToSlash(relativePath)
+			filesParams = append(filesParams, uploadParams{path, remoteAbsoluteFilename})
+		}
 
 		// Confirm user want to create those files in remote
 		fmt.Println("Following files are going to be created:")
@@ -406,8 +436,11 @@ func runMonitorItemUpload(cmd *cobra.Command, args []string) error {
 	}
 
 	s := &monitorFileUpload{uploader: client.NewMonitorUploader()}
-	c := utils.NewCoordinator(viper.GetInt("threads"))
-	c.DoWithItemsFromChannel(s, ch)
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
+	c.DoWithItemsFromChannel(cmd.Context(), s, ch)
 	return nil
 }
 
@@ -417,10 +450,16 @@ This command receives one file or folder path and uploads them to your
 VirusTotal Monitor account. It returns uploaded the file paths followed by their
 corresponding monitor ID.
 You can use the "vt monitor [monitor_id]" command for retrieving
-information about the it.`
+information about the it.
+
+Folders are walked recursively, unless --recursive=false is used. Use
+--include-files, --exclude-files, --max-size and --follow-symlinks for choosing
+which files are uploaded, and --dedupe for uploading only one file among the
+ones with the same content.`
 
 var monitorItemUploadCmdExample = `  vt monitor item upload foo.exe /remote_folder/foo.exe
-  vt monitor item upload myfolder/ /another_remote_folder/`
+  vt monitor item upload myfolder/ /another_remote_folder/
+  vt monitor item upload --exclude-files '*.tmp' myfolder/ /another_remote_folder/`
 
 // NewMonitorItemsUploadCmd returns a new instance of the 'mointor upload file' command.
 func NewMonitorItemsUploadCmd() *cobra.Command {
@@ -433,6 +472,8 @@ func NewMonitorItemsUploadCmd() *cobra.Command {
 		RunE:    runMonitorItemUpload,
 	}
 	addThreadsFlag(cmd.Flags())
+	addWalkFlags(cmd.Flags(), true)
+	addDedupeFlag(cmd.Flags(), false)
 	return cmd
 }
 
@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -23,7 +24,6 @@ import (
 	"github.com/VirusTotal/vt-cli/utils"
 	vt "github.com/VirusTotal/vt-go"
 	grab "github.com/cavaliergopher/grab/v3"
-	"github.com/fatih/color"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -62,7 +62,7 @@ type monitorPartnerDownloader struct {
 	fileDownloader
 }
 
-func (d *monitorPartnerDownloader) Do(file interface{}, ds *utils.DoerState) string {
+func (d *monitorPartnerDownloader) Do(ctx context.Context, file interface{}, ds *utils.DoerState) *utils.DoerResult {
 	var hash string
 	if f, isObject := file.(*vt.Object); isObject {
 		hash = f.ID()
@@ -70,15 +70,22 @@ func (d *monitorPartnerDownloader) Do(file interface{}, ds *utils.DoerState) str
 		hash = file.(string)
 	}
 
+	dstPath := path.Join(viper.GetString("output"), hash)
+	if existing, ok := d.existingSample(dstPath, hash); ok {
+		return skippedResult(hash, existing)
+	}
+
 	ds.Progress = fmt.Sprintf("%s %4.1f%%", hash, 0.0)
 
 	// Get download URL
 	var downloadURL string
+	var size int64
 	_, err := d.client.GetData(vt.URL("monitor_partner/files/%s/download_url", hash), &downloadURL)
 
+	part := partPath(dstPath)
 	if err == nil {
-		dstPath := path.Join(viper.GetString("output"), hash)
-		err = d.DownloadFile(downloadURL, dstPath, func(resp *grab.Response) {
+		_, err = d.fetch(ctx, downloadURL, part, func(resp *grab.Response) {
+			size = resp.BytesComplete()
 			progress := 100 * resp.Progress()
 			if progress < 100 {
 				ds.Progress = fmt.Sprintf("%s %4.1f%% %6.1f KBi/s",
@@ -86,22 +93,26 @@ func (d *monitorPartnerDownloader) Do(file interface{}, ds *utils.DoerState) str
 			}
 		})
 	}
-
-	msg := color.GreenString("ok")
-	if err != nil {
-		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
-			msg = color.RedString("not found")
-		} else {
-			msg = color.RedString(err.Error())
-		}
+	if err == nil {
+		_, err = verifyDownload(part, hash)
+	}
+	if err == nil {
+		dstPath, err = d.storeSample(part, dstPath)
 	}
 
-	return fmt.Sprintf("%s [%s]", hash, msg)
+	return downloadResult(hash, dstPath, size, err)
 }
 
 var monitorPartnerHashDownloadCmdHelp = `Download files from your partner account.
 
-This command download files from your monitor partner account using their sha256.`
+This command download files from your monitor partner account using their sha256.
+
+Files are downloaded to a temporary file that is renamed once the download is
+complete and verified against the requested SHA-256. Interrupted downloads are
+resumed the next time. With --skip-existing files that were already
+downloaded are not downloaded again, and with --store files are stored
+compressed with gzip, in a ZIP archive encrypted with the password "infected",
+or with a .vir extension.`
 
 var monitorPartnerHashDownloadCmdExample = `  vt monitorpartner download <sha256-1> <sha256-2> ...
   cat list_of_monitor_ids | vt monitorpartner download -`
@@ -130,9 +141,16 @@ func NewMonitorPartnerHashDownloadCmd() *cobra.Command {
 			re, _ := regexp.Compile("[[:xdigit:]]{64}")
 			monitorHashes := utils.NewFilteredStringReader(argReader, re)
 
-			c := utils.NewCoordinator(viper.GetInt("threads"))
-			c.DoWithStringsFromReader(
-				&monitorPartnerDownloader{fileDownloader: newFileDownloader(client)},
+			fd, err := newFileDownloader(client)
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			c.DoWithStringsFromReader(cmd.Context(),
+				&monitorPartnerDownloader{fileDownloader: fd},
 				monitorHashes)
 			return err
 		},
@@ -140,6 +158,7 @@ func NewMonitorPartnerHashDownloadCmd() *cobra.Command {
 
 	addThreadsFlag(cmd.Flags())
 	addOutputFlag(cmd.Flags())
+	addDownloadStoreFlags(cmd.Flags())
 	return cmd
 }
 
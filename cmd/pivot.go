@@ -0,0 +1,381 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/briandowns/spinner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pivotCollections maps the types of the objects included in the graphs built
+// by "vt pivot" to their collections in the API.
+var pivotCollections = map[string]string{
+	"file":       "files",
+	"url":        "urls",
+	"domain":     "domains",
+	"ip_address": "ip_addresses",
+}
+
+// pivotPageSize is the maximum number of objects that the API returns in each
+// page of a relationship.
+const pivotPageSize = 40
+
+// errRequestBudget is returned when the number of requests specified with
+// --max-requests is exhausted.
+var errRequestBudget = errors.New("request budget exhausted")
+
+// pivoter builds a graph by expanding the relationships of objects breadth
+// first, starting at a given object.
+type pivoter struct {
+	cli           *utils.APIClient
+	graph         *utils.Graph
+	relationships []string
+	// policy decides which objects are malicious.
+	policy *utils.Policy
+	// depth is the maximum distance from the starting object.
+	depth int
+	// maxNodes is the maximum number of nodes in the graph.
+	maxNodes int
+	// limit is the maximum number of objects retrieved for each
+	// relationship of each object.
+	limit int
+	// maxRequests is the maximum number of API requests, 0 means unlimited.
+	maxRequests int
+	requests    int
+	// truncated is true if some objects were not added to the graph
+	// because it reached maxNodes.
+	truncated bool
+	// progress is called with the node being expanded.
+	progress func(n *utils.GraphNode)
+}
+
+// request accounts for a new API request, returning errRequestBudget if it
+// exceeds --max-requests.
+func (p *pivoter) request() error {
+	if p.maxRequests > 0 && p.requests >= p.maxRequests {
+		return errRequestBudget
+	}
+	p.requests++
+	return nil
+}
+
+// verdict returns the verdict for an object with the given analysis stats,
+// which are nil if unknown.
+func (p *pivoter) verdict(stats map[string]int64) string {
+	switch {
+	case stats == nil:
+		return utils.VerdictUnknown
+	case p.policy.Eval(stats):
+		return utils.VerdictMalicious
+	case stats["suspicious"] > 0:
+		return utils.VerdictSuspicious
+	}
+	return utils.VerdictHarmless
+}
+
+// objectNode returns a node for the given object, or nil if the object's type
+// is not included in graphs. Resolutions, which are returned by the
+// "resolutions" relationship of domains and IP addresses, are converted to
+// the domain or IP address at the other end of the resolution.
+func (p *pivoter) objectNode(from *utils.GraphNode, obj *vt.Object) *utils.GraphNode {
+	n := &utils.GraphNode{Type: obj.Type(), ID: obj.ID(), Depth: from.Depth + 1}
+	statsAttr := "last_analysis_stats"
+	if obj.Type() == "resolution" {
+		switch from.Type {
+		case "domain":
+			n.Type = "ip_address"
+			n.ID, _ = obj.GetString("ip_address")
+			statsAttr = "ip_address_last_analysis_stats"
+		case "ip_address":
+			n.Type = "domain"
+			n.ID, _ = obj.GetString("host_name")
+			statsAttr = "host_name_last_analysis_stats"
+		}
+	}
+	if _, ok := pivotCollections[n.Type]; !ok || n.ID == "" {
+		utils.Debugf("not including %s %s in the graph", obj.Type(), obj.ID())
+		return nil
+	}
+	if v, err := obj.Get(statsAttr); err == nil {
+		if m, ok := v.(map[string]interface{}); ok {
+			n.Stats = make(map[string]int64)
+			for name := range m {
+				n.Stats[name], _ = obj.GetInt64(statsAttr + "." + name)
+			}
+		}
+	}
+	n.Verdict = p.verdict(n.Stats)
+	switch n.Type {
+	case "file":
+		n.Label, _ = obj.GetString("meaningful_name")
+	case "url":
+		n.Label, _ = obj.GetString("url")
+	}
+	return n
+}
+
+// applies returns true if the relationship exists for objects of the given
+// type. If the relationships are unknown, because the cache written by
+// "vt init" is missing, all relationships are assumed to exist.
+func applies(objectType, relationship string) bool {
+	relationships, ok := objectRelationshipsMap[objectType]
+	if !ok {
+		return true
+	}
+	for _, r := range relationships {
+		if r.Name == relationship {
+			return true
+		}
+	}
+	return false
+}
+
+// expand adds the objects related to n to the graph, and returns the ones that
+// were not in the graph yet.
+func (p *pivoter) expand(n *utils.GraphNode) ([]*utils.GraphNode, error) {
+	var added []*utils.GraphNode
+	for _, relationship := range p.relationships {
+		if !applies(n.Type, relationship) {
+			continue
+		}
+		// The first page is accounted for here, the rest once they have
+		// been retrieved. The iterator fetches pages in advance, so it is
+		// limited to the pages that the remaining budget allows.
+		if err := p.request(); err != nil {
+			return added, err
+		}
+		batchSize := pivotPageSize
+		if p.limit < batchSize {
+			batchSize = p.limit
+		}
+		limit := p.limit
+		if p.maxRequests > 0 {
+			if pages := p.maxRequests - p.requests + 1; pages*batchSize < limit {
+				limit = pages * batchSize
+			}
+		}
+		it, err := p.cli.Iterator(
+			vt.URL("%s/%s/%s", pivotCollections[n.Type], n.ID, relationship),
+			vt.IteratorLimit(limit),
+			vt.IteratorBatchSize(batchSize))
+		if err != nil {
+			return added, err
+		}
+		count := 0
+		for it.Next() {
+			// Pages after the first one are accounted for when their first
+			// object is retrieved.
+			if count > 0 && count%batchSize == 0 {
+				p.requests++
+			}
+			count++
+			child := p.objectNode(n, it.Get())
+			if child == nil {
+				continue
+			}
+			if existing := p.graph.Node(child.Type, child.ID); existing != nil {
+				child = existing
+			} else if len(p.graph.Nodes) >= p.maxNodes {
+				p.truncated = true
+				continue
+			} else {
+				p.graph.AddNode(child)
+				added = append(added, child)
+			}
+			p.graph.AddEdge(n, child, relationship)
+		}
+		err = it.Error()
+		it.Close()
+		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "QuotaExceededError" {
+			return added, err
+		} else if err != nil {
+			utils.Debugf("getting %s for %s: %v", relationship, n.Key(), err)
+		}
+	}
+	return added, nil
+}
+
+// Pivot builds the graph starting at the object in the given API path,
+// expanding the objects breadth first until reaching the maximum depth or
+// the maximum number of nodes. If the work is stopped because the quota or
+// the request budget is exhausted, the graph built so far is kept and the
+// error is returned.
+func (p *pivoter) Pivot(path string) error {
+	if err := p.request(); err != nil {
+		return err
+	}
+	obj, err := p.cli.GetObject(vt.URL("%s", path))
+	if err != nil {
+		return err
+	}
+	seed := p.objectNode(&utils.GraphNode{Depth: -1}, obj)
+	if seed == nil {
+		return fmt.Errorf("can't pivot from %s objects", obj.Type())
+	}
+	p.graph.AddNode(seed)
+	queue := []*utils.GraphNode{seed}
+	// Once the graph is full no more objects are expanded, as that would
+	// spend requests for adding edges only.
+	for len(queue) > 0 && len(p.graph.Nodes) < p.maxNodes {
+		n := queue[0]
+		queue = queue[1:]
+		if n.Depth >= p.depth {
+			continue
+		}
+		p.progress(n)
+		added, err := p.expand(n)
+		queue = append(queue, added...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var pivotCmdHelp = `Build a graph of the objects related to an indicator.
+
+This command starts at a file, URL, domain or IP address and follows the
+relationships specified with --relationships breadth first, like
+contacted_domains for files or resolutions for domains and IP addresses,
+building a graph of the related files, URLs, domains and IP addresses. Objects
+at a distance of --depth relationships from the starting one are not
+expanded, and up to --limit objects are retrieved for each relationship of
+each object. Each object is included only once, and the graph is not
+expanded any further once it has --max-nodes nodes.
+
+Each relationship of each object takes an API request for every 40 objects
+retrieved, or for every --limit objects if it is lower. Relationships that
+don't exist for an object type are skipped without a request if the
+relationships were cached by "vt init". With --max-requests the number of
+requests is limited. If the limit is reached, or the API quota is exceeded,
+the graph built so far is printed with a warning.
+
+The graph is printed to the standard output in the format specified with
+--graph-format: Graphviz DOT (dot), GraphML (graphml) or Cytoscape JSON
+(cytoscape). Nodes are colored according to their verdict: red for the ones
+matching the --flag-on policy, orange for other objects with suspicious
+detections, green for the ones without them, and grey for the ones whose
+analysis stats are unknown.`
+
+var pivotCmdExample = `  vt pivot 44d88612fea8a8f36de82e1278abb02f | dot -Tsvg > graph.svg
+  vt pivot --relationships contacted_domains,resolutions,communicating_files --depth 3 --max-nodes 500 evil.example.com
+  vt pivot --graph-format cytoscape --max-requests 100 --flag-on 'malicious>=3' 192.0.2.1 > graph.json`
+
+// NewPivotCmd returns a new instance of the 'pivot' command.
+func NewPivotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "pivot [ioc]",
+		Short:   "Build a graph of the objects related to an indicator",
+		Long:    pivotCmdHelp,
+		Example: pivotCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := objectPath(viper.GetString("type"), args[0])
+			if err != nil {
+				return err
+			}
+			graph := utils.NewGraph()
+			var write func() error
+			switch format := viper.GetString("graph-format"); format {
+			case "dot":
+				write = func() error { return graph.WriteDOT(os.Stdout) }
+			case "graphml":
+				write = func() error { return graph.WriteGraphML(os.Stdout) }
+			case "cytoscape":
+				write = func() error { return graph.WriteCytoscape(os.Stdout) }
+			default:
+				return fmt.Errorf("invalid graph format %q, use dot, graphml or cytoscape", format)
+			}
+			policy, err := utils.ParsePolicy(viper.GetString("flag-on"))
+			if err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			if viper.GetInt("limit") <= 0 {
+				return errors.New("--limit must be greater than 0")
+			}
+			p := &pivoter{
+				cli:           client,
+				graph:         graph,
+				relationships: viper.GetStringSlice("relationships"),
+				policy:        policy,
+				depth:         viper.GetInt("depth"),
+				maxNodes:      viper.GetInt("max-nodes"),
+				limit:         viper.GetInt("limit"),
+				maxRequests:   viper.GetInt("max-requests"),
+				progress:      func(n *utils.GraphNode) {},
+			}
+			if !viper.GetBool("silent") {
+				// The graph is written to stdout, progress goes to stderr.
+				spin := spinner.New(spinner.CharSets[6], 250*time.Millisecond,
+					spinner.WithWriter(os.Stderr))
+				spin.Color("green")
+				spin.Start()
+				defer spin.Stop()
+				p.progress = func(n *utils.GraphNode) {
+					spin.Suffix = fmt.Sprintf(" %d nodes, expanding %s...",
+						len(graph.Nodes), n.Key())
+				}
+			}
+
+			err = p.Pivot(path)
+			if len(graph.Nodes) == 0 {
+				return err
+			}
+			if err != nil {
+				utils.Warnf("graph incomplete, stopped after %d requests: %v", p.requests, err)
+			}
+			if p.truncated {
+				utils.Warnf("graph truncated at %d nodes, use --max-nodes for a larger graph", p.maxNodes)
+			}
+			return write()
+		},
+	}
+
+	addObjectTypeFlag(cmd.Flags())
+	addLimitFlag(cmd.Flags())
+	cmd.Flags().StringSlice(
+		"relationships",
+		[]string{"contacted_domains", "contacted_ips", "contacted_urls", "resolutions", "communicating_files"},
+		"relationships that are followed, like contacted_domains or resolutions")
+	cmd.Flags().Int(
+		"depth", 2,
+		"maximum number of relationships between the starting object and any other")
+	cmd.Flags().Int(
+		"max-nodes", 200,
+		"maximum number of nodes in the graph")
+	cmd.Flags().Int(
+		"max-requests", 0,
+		"maximum number of API requests, 0 means unlimited")
+	cmd.Flags().String(
+		"graph-format", "dot",
+		"format of the graph: dot, graphml or cytoscape")
+	cmd.Flags().String(
+		"flag-on", "malicious>=1",
+		"policy that decides which objects are colored as malicious (e.g. 'malicious>=3')")
+
+	return cmd
+}
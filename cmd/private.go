@@ -0,0 +1,476 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// storageRegions contains the regions where files submitted for private
+// scanning can be stored.
+var storageRegions = []string{"US", "CA", "EU", "GB"}
+
+// privateScanParams returns the parameters sent with files and URLs submitted
+// for private scanning, according to the command-line flags. If sandbox is
+// true the sandbox options, which only apply to files, are included too.
+func privateScanParams(sandbox bool) (map[string]string, error) {
+	params := make(map[string]string)
+	if days := viper.GetInt("retention-period"); days != 0 {
+		if days < 1 || days > 28 {
+			return nil, fmt.Errorf("invalid retention period %d, it must be between 1 and 28 days", days)
+		}
+		params["retention_period_days"] = strconv.Itoa(days)
+	}
+	if region := strings.ToUpper(viper.GetString("storage-region")); region != "" {
+		valid := false
+		for _, r := range storageRegions {
+			valid = valid || r == region
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid storage region %q, use %s",
+				region, strings.Join(storageRegions, ", "))
+		}
+		params["storage_region"] = region
+	}
+	if !sandbox {
+		return params, nil
+	}
+
+	if viper.GetBool("disable-sandbox") {
+		for _, flag := range []string{"enable-internet", "command-line", "interaction-timeout", "locale"} {
+			if viper.IsSet(flag) {
+				return nil, fmt.Errorf("--%s can't be used with --disable-sandbox", flag)
+			}
+		}
+		params["disable_sandbox"] = "true"
+	}
+	if viper.GetBool("enable-internet") {
+		params["enable_internet"] = "true"
+	}
+	if cmdLine := viper.GetString("command-line"); cmdLine != "" {
+		params["command_line"] = cmdLine
+	}
+	if timeout := viper.GetDuration("interaction-timeout"); timeout != 0 {
+		if timeout < time.Minute || timeout > 30*time.Minute {
+			return nil, fmt.Errorf("invalid interaction timeout %v, it must be between 1m and 30m", timeout)
+		}
+		params["interaction_timeout"] = strconv.Itoa(int(timeout.Seconds()))
+	}
+	if locale := viper.GetString("locale"); locale != "" {
+		params["locale"] = locale
+	}
+	if password := viper.GetString("password"); password != "" {
+		params["password"] = password
+	}
+	return params, nil
+}
+
+// privateAnalysisResult returns the result for an item submitted for private
+// scanning. If wait is true the function waits for the analysis to complete
+// and includes the analysed object in the result.
+func privateAnalysisResult(ctx context.Context, cli *utils.APIClient, item, analysisID string, wait bool, ds *utils.DoerState) *utils.DoerResult {
+	res := utils.NewDoerResult(item, "ok").With("analysis_id", analysisID)
+	if wait {
+		obj, err := waitForAnalysis(ctx, cli, "private/analyses", analysisID, ds)
+		if err != nil {
+			return utils.NewDoerError(item, err).With("analysis_id", analysisID)
+		}
+		res.Object = obj
+		return res
+	}
+	res.Text = fmt.Sprintf("%s %s", item, analysisID)
+	return res
+}
+
+type privateFileScanner struct {
+	scanner           *utils.PrivateScanner
+	cli               *utils.APIClient
+	params            map[string]string
+	waitForCompletion bool
+}
+
+func (s *privateFileScanner) Do(ctx context.Context, path interface{}, ds *utils.DoerState) *utils.DoerResult {
+	progressCh := uploadProgress(path.(string), ds)
+	defer close(progressCh)
+
+	f, err := os.Open(path.(string))
+	if err != nil {
+		return utils.NewDoerError(path.(string), err)
+	}
+	defer f.Close()
+
+	analysis, err := s.scanner.ScanFile(f, progressCh, s.params)
+	if err != nil {
+		return utils.NewDoerError(path.(string), err)
+	}
+	return privateAnalysisResult(ctx, s.cli, path.(string), analysis.ID(),
+		s.waitForCompletion, ds)
+}
+
+type privateURLScanner struct {
+	scanner           *utils.PrivateScanner
+	cli               *utils.APIClient
+	params            map[string]string
+	waitForCompletion bool
+}
+
+func (s *privateURLScanner) Do(ctx context.Context, url interface{}, ds *utils.DoerState) *utils.DoerResult {
+	analysis, err := s.scanner.ScanURL(url.(string), s.params)
+	if err != nil {
+		return utils.NewDoerError(url.(string), err)
+	}
+	return privateAnalysisResult(ctx, s.cli, url.(string), analysis.ID(),
+		s.waitForCompletion, ds)
+}
+
+var privateScanFileCmdHelp = `Scan one or more files privately.
+
+This command works like "vt scan file", but files are submitted to VirusTotal's
+private scanning, which requires a private scanning license. Privately scanned
+files are not shared with anyone outside your organization, and are deleted
+after the retention period.
+
+Files are analysed in a sandbox too, unless --disable-sandbox is used. Use
+--enable-internet for letting the sample connect to the internet during its
+execution, --command-line for passing it arguments, --interaction-timeout for
+setting how long it runs, and --locale for choosing the locale of the sandbox,
+like EN_US. The retention period, in days, is set with --retention-period and
+the region where files are stored with --storage-region.
+
+The command returns the file paths followed by their corresponding analysis
+IDs, use "vt private analysis" for retrieving the analyses or --wait for
+waiting until the analyses are completed and showing the results.`
+
+var privateScanFileCmdExample = `  vt private scan file foo.exe
+  vt private scan file --wait --retention-period 7 --storage-region EU foo.exe
+  vt private scan file --enable-internet --command-line '/silent' --interaction-timeout 5m setup.exe
+  vt private scan file --recursive --include-files '*.docx' samples/`
+
+// NewPrivateScanFileCmd returns a new instance of the 'private scan file'
+// command.
+func NewPrivateScanFileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "file [[dir] | [file]...]",
+		Short:   "Scan one or more files privately",
+		Long:    privateScanFileCmdHelp,
+		Example: privateScanFileCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := privateScanParams(true)
+			if err != nil {
+				return err
+			}
+			var paths []string
+			r := utils.StringReaderFromCmdArgs(args)
+			for s, err := r.ReadString(); s != "" || err == nil; s, err = r.ReadString() {
+				paths = append(paths, s)
+			}
+			files, err := collectFiles(paths)
+			if err != nil {
+				return err
+			}
+			gate, err := newScanGate()
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			c.Gate = gate
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			s := &privateFileScanner{
+				scanner:           client.NewPrivateScanner(),
+				cli:               client,
+				params:            params,
+				waitForCompletion: viper.GetBool("wait")}
+			ctx, stop := utils.WithInterrupt(cmd.Context())
+			defer stop()
+			c.DoWithStringsFromReader(ctx, s, utils.NewStringArrayReader(files.Paths))
+			return gateError(cmd, gate)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addPasswordFlag(cmd.Flags())
+	addWaitForCompletionFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
+	addWalkFlags(cmd.Flags(), false)
+	addDedupeFlag(cmd.Flags(), false)
+	addRetentionFlags(cmd.Flags())
+	cmd.Flags().Bool(
+		"disable-sandbox", false,
+		"don't analyse the files in a sandbox")
+	cmd.Flags().Bool(
+		"enable-internet", false,
+		"let the files connect to the internet while running in the sandbox")
+	cmd.Flags().String(
+		"command-line", "",
+		"command-line arguments used when running the files in the sandbox")
+	cmd.Flags().Duration(
+		"interaction-timeout", 0,
+		"time that the files run in the sandbox, between 1m and 30m")
+	cmd.Flags().String(
+		"locale", "",
+		"locale of the sandbox, like EN_US")
+	cmd.MarkZshCompPositionalArgumentFile(1)
+
+	return cmd
+}
+
+var privateScanURLCmdHelp = `Scan one or more URLs privately.
+
+This command works like "vt scan url", but URLs are submitted to VirusTotal's
+private scanning, which requires a private scanning license. Privately scanned
+URLs are not shared with anyone outside your organization, and are deleted
+after the retention period, which is set in days with --retention-period.
+
+The command returns the URLs followed by their corresponding analysis IDs, use
+"vt private analysis" for retrieving the analyses or --wait for waiting until
+the analyses are completed and showing the results.`
+
+var privateScanURLCmdExample = `  vt private scan url http://foo.com
+  vt private scan url --wait --retention-period 1 http://foo.com http://bar.com
+  cat list_of_urls | vt private scan url -`
+
+// NewPrivateScanURLCmd returns a new instance of the 'private scan url'
+// command.
+func NewPrivateScanURLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "url [url]...",
+		Short:   "Scan one or more URLs privately",
+		Long:    privateScanURLCmdHelp,
+		Example: privateScanURLCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := privateScanParams(false)
+			if err != nil {
+				return err
+			}
+			gate, err := newScanGate()
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			c.Gate = gate
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			s := &privateURLScanner{
+				scanner:           client.NewPrivateScanner(),
+				cli:               client,
+				params:            params,
+				waitForCompletion: viper.GetBool("wait")}
+			c.DoWithStringsFromReader(cmd.Context(), s, utils.StringReaderFromCmdArgs(args))
+			return gateError(cmd, gate)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addWaitForCompletionFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
+	addRetentionFlags(cmd.Flags())
+
+	return cmd
+}
+
+func addRetentionFlags(flags *pflag.FlagSet) {
+	flags.Int(
+		"retention-period", 0,
+		"number of days, between 1 and 28, that the submissions are kept (default: the account's setting)")
+	flags.String(
+		"storage-region", "",
+		"region where the submissions are stored: "+strings.Join(storageRegions, ", "))
+}
+
+var privateScanCmdHelp = `Scan files or URLs privately.
+
+This group of commands allow to scan files and URLs with VirusTotal's private
+scanning.`
+
+// NewPrivateScanCmd returns a new instance of the 'private scan' command.
+func NewPrivateScanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Scan files or URLs privately",
+		Long:  privateScanCmdHelp,
+	}
+
+	cmd.AddCommand(NewPrivateScanFileCmd())
+	cmd.AddCommand(NewPrivateScanURLCmd())
+
+	return cmd
+}
+
+var privateFileCmdHelp = `Get information about one or more privately scanned files.
+
+This command receives one or more hashes (SHA-256, SHA-1 or MD5) of files
+scanned with "vt private scan file" and returns information about them.
+
+If the command receives a single hypen (-) the hashes are read from the
+standard input, one per line.`
+
+var privateFileCmdExample = `  vt private file 8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85
+  cat list_of_hashes | vt private file -`
+
+// NewPrivateFileCmd returns a new instance of the 'private file' command.
+func NewPrivateFileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "file [hash]...",
+		Short:   "Get information about privately scanned files",
+		Long:    privateFileCmdHelp,
+		Example: privateFileCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			re, _ := regexp.Compile("[[:xdigit:]]{64}|[[:xdigit:]]{40}|[[:xdigit:]]{32}")
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.GetAndPrintObjects("private/files/%s",
+				utils.StringReaderFromCmdArgs(args), re)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+
+	return cmd
+}
+
+var privateURLCmdHelp = `Get information about one or more privately scanned URLs.
+
+This command receives one or more URLs scanned with "vt private scan url", or
+their identifiers, and returns information about them.
+
+If the command receives a single hypen (-) the URLs are read from the standard
+input, one per line.`
+
+var privateURLCmdExample = `  vt private url http://foo.com
+  cat list_of_urls | vt private url -`
+
+// NewPrivateURLCmd returns a new instance of the 'private url' command.
+func NewPrivateURLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "url [url]...",
+		Short:   "Get information about privately scanned URLs",
+		Long:    privateURLCmdHelp,
+		Example: privateURLCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			r := utils.NewMappedStringReader(
+				utils.StringReaderFromCmdArgs(args), urlObjectID)
+			return p.GetAndPrintObjects("private/urls/%s", r, nil)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+
+	return cmd
+}
+
+var privateAnalysisCmdHelp = `Get one or more private analyses.
+
+This command receives one or more identifiers of analyses returned by
+"vt private scan file" or "vt private scan url", and returns information about
+the analyses.
+
+If the command receives a single hypen (-) the analysis identifiers are read
+from the standard input, one per line.`
+
+var privateAnalysisCmdExample = `  vt private analysis MDJiY2FiZmZmZmQxNmZlMGZjMjUwZjA4Y2FkOTVlMGM6MTU0NjQ1NDUyMA==
+  cat list_of_analysis_ids | vt private analysis -`
+
+// NewPrivateAnalysisCmd returns a new instance of the 'private analysis'
+// command.
+func NewPrivateAnalysisCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "analysis [id]...",
+		Short:   "Get private analyses",
+		Long:    privateAnalysisCmdHelp,
+		Example: privateAnalysisCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := NewPrinter(cmd)
+			if err != nil {
+				return err
+			}
+			return p.GetAndPrintObjects("private/analyses/%s",
+				utils.StringReaderFromCmdArgs(args), nil)
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addIDOnlyFlag(cmd.Flags())
+
+	return cmd
+}
+
+var privateCmdHelp = `Scan files and URLs privately and get the results.
+
+This group of commands use VirusTotal's private scanning, where files and URLs
+are analysed without being shared with anyone outside your organization. It
+requires a private scanning license.
+
+Reference:
+  https://docs.virustotal.com/reference/private-scanning`
+
+// NewPrivateCmd returns a new instance of the 'private' command.
+func NewPrivateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "private",
+		Short: "Scan files and URLs privately",
+		Long:  privateCmdHelp,
+	}
+
+	cmd.AddCommand(NewPrivateScanCmd())
+	cmd.AddCommand(NewPrivateFileCmd())
+	cmd.AddCommand(NewPrivateURLCmd())
+	cmd.AddCommand(NewPrivateAnalysisCmd())
+
+	return cmd
+}
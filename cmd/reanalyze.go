@@ -0,0 +1,154 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// reanalyzer is a Doer that asks VirusTotal to analyse again existing files
+// or URLs.
+type reanalyzer struct {
+	cli *utils.APIClient
+	// collection is the API collection of the objects, like "files".
+	collection string
+	// guiPath is the path for analyses in VirusTotal's web GUI.
+	guiPath string
+	// objectID returns the object's ID for an item received by the doer.
+	objectID          func(string) string
+	showInVT          bool
+	waitForCompletion bool
+	// If olderThan is not zero, only objects whose last analysis is older
+	// than olderThan are reanalysed.
+	olderThan time.Duration
+}
+
+func (r *reanalyzer) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	s := item.(string)
+	path := fmt.Sprintf("%s/%s", r.collection, r.objectID(s))
+
+	if r.olderThan > 0 {
+		ds.Progress = fmt.Sprintf("%s looking up...", s)
+		obj, err := r.cli.GetObject(vt.URL("%s", path))
+		if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+			return &utils.DoerResult{Item: s, Status: "not found", Error: err}
+		} else if err != nil {
+			return utils.NewDoerError(s, err)
+		}
+		if date, err := obj.GetTime("last_analysis_date"); err == nil && time.Since(date) <= r.olderThan {
+			return utils.NewDoerResult(s, "skipped").With("last_analysis_date", date.Unix())
+		}
+	}
+
+	ds.Progress = fmt.Sprintf("%s requesting analysis...", s)
+	analysis, err := r.cli.Reanalyse(path)
+	if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+		return &utils.DoerResult{Item: s, Status: "not found", Error: err}
+	} else if err != nil {
+		return utils.NewDoerError(s, err)
+	}
+	return analysisResult(ctx, r.cli, s, analysis.ID(),
+		r.guiPath, r.showInVT, r.waitForCompletion, ds)
+}
+
+var reanalyzeCmdHelp = `Reanalyse one or more %[1]s.
+
+This command receives one or more %[2]s and asks VirusTotal to analyse the
+corresponding %[1]s again, so that their reports are up to date. It returns
+the %[2]s followed by the IDs of the new analyses. You can use the
+"vt analysis" command for retrieving information about the analyses or you can
+use the --wait flag to see the results when the analysis is completed.
+
+If the command receives a single hypen (-) the %[2]s are read from the standard
+input, one per line. With --older-than only the %[1]s whose last analysis is
+older than the given age, like 7d or 12h, are reanalysed, the rest are
+reported as skipped.`
+
+// NewReanalyzeCmd returns a new instance of the 'reanalyze' command for the
+// "files" or "urls" collection.
+func NewReanalyzeCmd(collection string) *cobra.Command {
+	objectType, objects, items, guiPath := "file", "files", "hashes", "file-analysis"
+	use, example := "[hash]", "44d88612fea8a8f36de82e1278abb02f"
+	objectID := func(s string) string { return s }
+	iocTypes := []utils.IOCType{utils.IOCMD5, utils.IOCSHA1, utils.IOCSHA256}
+	if collection == "urls" {
+		objectType, objects, items, guiPath = "url", "URLs", "URLs", "url-analysis"
+		use, example = "[url]", "http://www.example.com"
+		iocTypes = []utils.IOCType{utils.IOCURL}
+		objectID = func(s string) string {
+			if urlID.MatchString(s) {
+				return s
+			}
+			return base64.RawURLEncoding.EncodeToString([]byte(s))
+		}
+	}
+
+	cmd := &cobra.Command{
+		Aliases: []string{"reanalyse"},
+		Use:     fmt.Sprintf("reanalyze %s...", use),
+		Short:   fmt.Sprintf("Reanalyse %s", objects),
+		Long:    fmt.Sprintf(reanalyzeCmdHelp, objects, items),
+		Example: fmt.Sprintf(`  vt %[1]s reanalyze %[2]s
+  vt %[1]s reanalyze --wait %[2]s
+  cat blocklist | vt %[1]s reanalyze --older-than 7d -`, objectType, example),
+		Args: cobra.MinimumNArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			olderThan, err := utils.ParseDuration(viper.GetString("older-than"))
+			if err != nil {
+				return err
+			}
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			r := &reanalyzer{
+				cli:               client,
+				collection:        collection,
+				guiPath:           guiPath,
+				objectID:          objectID,
+				showInVT:          viper.GetBool("open"),
+				waitForCompletion: viper.GetBool("wait"),
+				olderThan:         olderThan,
+			}
+			ctx, stop := utils.WithInterrupt(cmd.Context())
+			defer stop()
+			c.DoWithStringsFromReader(ctx, r, stringReaderFromCmdArgs(args, iocTypes...))
+			return nil
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addOpenInVTFlag(cmd.Flags())
+	addWaitForCompletionFlag(cmd.Flags())
+	addIncludeExcludeFlags(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+	cmd.Flags().String(
+		"older-than", "",
+		fmt.Sprintf("reanalyse only the %s whose last analysis is older than this (e.g. 7d)", objects))
+
+	return cmd
+}
@@ -154,6 +154,10 @@ func NewRelationshipsCmd(collection, objectType, use string) *cobra.Command {
 func addRelationshipCmds(cmd *cobra.Command, collection, objectType, use string) {
 	relationships := objectRelationshipsMap[objectType]
 	for _, r := range relationships {
+		// Some relationships have their own command, like "file behaviours".
+		if sub, _, err := cmd.Find([]string{r.Name}); err == nil && sub != cmd {
+			continue
+		}
 		cmd.AddCommand(NewRelationshipCmd(collection, r.Name, use, r.Description))
 	}
 	cmd.AddCommand(NewRelationshipsCmd(collection, objectType, use))
@@ -268,7 +268,7 @@ func NewRetrohuntDeleteCmd() *cobra.Command {
 				go func(jobID string) {
 					url := vt.URL("intelligence/retrohunt_jobs/%s", jobID)
 					if _, err := client.Delete(url); err != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", err)
+						utils.Errorf("%v", err)
 					}
 					wg.Done()
 				}(arg)
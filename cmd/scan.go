@@ -15,6 +15,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -28,36 +29,69 @@ import (
 )
 
 const (
-	// PollFrequency defines the interval in which requests are sent to the
-	// VT API to check if the analysis is completed.
-	PollFrequency = 10 * time.Second
-	// TimeoutLimit defines the maximum amount of minutes to wait for an
+	// DefaultPollInterval is the default interval in which requests are sent
+	// to the VT API to check if an analysis is completed.
+	DefaultPollInterval = 10 * time.Second
+	// DefaultMaxPollInterval is the default maximum for the poll interval,
+	// which grows after each request.
+	DefaultMaxPollInterval = time.Minute
+	// DefaultWaitTimeout is the default maximum amount of time to wait for an
 	// analysis' results.
-	TimeoutLimit = 10 * time.Minute
+	DefaultWaitTimeout = 10 * time.Minute
 )
 
-// waitForAnalysisResults calls every PollFrequency seconds to the VT API and
-// checks whether an analysis is completed or not. When the analysis is completed
-// it is returned.
-func waitForAnalysisResults(cli *utils.APIClient, analysisId string, ds *utils.DoerState) (*vt.Object, error) {
-	ds.Progress = "Waiting for analysis completion..."
-	ticker := time.NewTicker(PollFrequency)
-	defer ticker.Stop()
-	ctx, cancel := context.WithTimeout(context.Background(), TimeoutLimit)
-	defer cancel()
+// pollBackoff is the factor by which the poll interval is multiplied after
+// each request, until it reaches the maximum interval.
+const pollBackoff = 1.5
+
+// waitForAnalysisResults polls the VT API for checking whether an analysis is
+// completed or not. The first request is sent after the interval specified
+// with --poll-interval, and the interval grows after each request up to
+// --max-poll-interval. When the analysis is completed the analysed object is
+// returned. If the analysis doesn't complete within --wait-timeout an error is
+// returned.
+func waitForAnalysisResults(ctx context.Context, cli *utils.APIClient, analysisId string, ds *utils.DoerState) (*vt.Object, error) {
+	return waitForAnalysis(ctx, cli, "analyses", analysisId, ds)
+}
+
+// waitForAnalysis works like waitForAnalysisResults, but the analysis is
+// retrieved from the given collection, which is "analyses" for public
+// analyses and "private/analyses" for private ones.
+func waitForAnalysis(ctx context.Context, cli *utils.APIClient, collection, analysisId string, ds *utils.DoerState) (*vt.Object, error) {
+	interval := viper.GetDuration("poll-interval")
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	maxInterval := viper.GetDuration("max-poll-interval")
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	timeout := viper.GetDuration("wait-timeout")
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+
+	ds.Progress = fmt.Sprintf("%s waiting for analysis completion...", analysisId)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	deadline := time.After(timeout)
 	i := 1
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-ticker.C:
-			ds.Progress = fmt.Sprintf("Waiting for analysis completion...%s", strings.Repeat(".", i))
+		case <-deadline:
+			ds.Progress = ""
+			return nil, fmt.Errorf("analysis %s not completed after %v", analysisId, timeout)
+		case <-timer.C:
+			ds.Progress = fmt.Sprintf("%s waiting for analysis completion...%s",
+				analysisId, strings.Repeat(".", i))
 			i++
-			if obj, err := cli.GetObject(vt.URL(fmt.Sprintf("analyses/%s", analysisId))); err != nil {
+			if obj, err := cli.GetObject(vt.URL("%s/%s", collection, analysisId)); err != nil {
 				// If the API returned an error 503 (transient error) retry; otherwise just return
 				// the error to the user.
-				if e, ok := err.(*vt.Error); !ok || e.Code != "TransientError" {
+				if e, ok := err.(vt.Error); !ok || e.Code != "TransientError" {
 					ds.Progress = ""
 					return nil, fmt.Errorf("error retrieving analysis result: %v", err)
 				}
@@ -65,8 +99,13 @@ func waitForAnalysisResults(cli *utils.APIClient, analysisId string, ds *utils.D
 				ds.Progress = ""
 				// Request the full object report and return it instead of just
 				// the analysis results.
-				return cli.GetObject(vt.URL(fmt.Sprintf("analyses/%s/item", analysisId)))
+				return cli.GetObject(vt.URL("%s/%s/item", collection, analysisId))
 			}
+			interval = time.Duration(float64(interval) * pollBackoff)
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+			timer.Reset(interval)
 		}
 	}
 }
@@ -74,30 +113,84 @@ func waitForAnalysisResults(cli *utils.APIClient, analysisId string, ds *utils.D
 type fileScanner struct {
 	scanner           *vt.FileScanner
 	cli               *utils.APIClient
-	printer           *utils.Printer
 	showInVT          bool
 	waitForCompletion bool
 	password          string
+	// If skipKnown is true files are hashed and looked up before uploading
+	// them, and only the ones unknown to VirusTotal are uploaded.
+	skipKnown bool
+	// If maxAge is not zero known files are looked up like with skipKnown,
+	// and the ones with an analysis older than maxAge are reanalysed.
+	maxAge time.Duration
 }
 
-func (s *fileScanner) Do(path interface{}, ds *utils.DoerState) string {
+func (s *fileScanner) Do(ctx context.Context, path interface{}, ds *utils.DoerState) *utils.DoerResult {
+	if !s.skipKnown && s.maxAge == 0 {
+		return s.upload(ctx, path.(string), ds)
+	}
 
-	progressCh := make(chan float32)
-	defer close(progressCh)
+	ds.Progress = fmt.Sprintf("%s hashing...", path)
+	h, err := utils.HashFile(path.(string))
+	if err != nil {
+		return utils.NewDoerError(path.(string), err)
+	}
+	ds.Progress = fmt.Sprintf("%s looking up...", path)
+	obj, err := s.cli.GetObject(vt.URL("files/%s", h.SHA256))
+	if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+		return withAction(s.upload(ctx, path.(string), ds), "uploaded").With("sha256", h.SHA256)
+	} else if err != nil {
+		return utils.NewDoerError(path.(string), err).With("sha256", h.SHA256)
+	}
 
-	go func() {
-		for progress := range progressCh {
-			if progress < 100 {
-				ds.Progress = fmt.Sprintf("%s uploading... %4.1f%%", path, progress)
-			} else {
-				ds.Progress = fmt.Sprintf("%s scanning...", path)
-			}
+	date, err := obj.GetTime("last_analysis_date")
+	if s.maxAge > 0 && (err != nil || time.Since(date) > s.maxAge) {
+		analysis, err := s.cli.Reanalyse("files/" + h.SHA256)
+		if err != nil {
+			return utils.NewDoerError(path.(string), err).With("sha256", h.SHA256)
 		}
-	}()
+		res := analysisResult(ctx, s.cli, path.(string), analysis.ID(),
+			"file-analysis", s.showInVT, s.waitForCompletion, ds)
+		return withAction(res, "reanalysed").With("sha256", h.SHA256)
+	}
+
+	res := utils.NewDoerResult(path.(string), "ok").With("sha256", h.SHA256)
+	if err == nil {
+		res.With("last_analysis_date", date.Unix())
+	}
+	if s.showInVT {
+		url := fmt.Sprintf("https://www.virustotal.com/gui/file/%s", h.SHA256)
+		res.With("url", url)
+		res.Text = fmt.Sprintf("%s %s", path, url)
+	} else if s.waitForCompletion {
+		// The file's report is already up to date, show it as if the file was
+		// just analysed.
+		res.Object = obj
+	} else {
+		res.Text = fmt.Sprintf("%s %s", path, h.SHA256)
+	}
+	return withAction(res, "skipped")
+}
+
+// withAction adds to the result of scanning a file the action taken for the
+// file, which is "uploaded", "reanalysed" or "skipped" if the file was already
+// known to VirusTotal. The action is also shown in the human-friendly output.
+func withAction(res *utils.DoerResult, action string) *utils.DoerResult {
+	res.With("action", action)
+	if res.Error == nil && res.Text != "" {
+		res.Text = fmt.Sprintf("%s [%s]", res.Text, action)
+	}
+	return res
+}
+
+// upload uploads a file for scanning.
+func (s *fileScanner) upload(ctx context.Context, path string, ds *utils.DoerState) *utils.DoerResult {
 
-	f, err := os.Open(path.(string))
+	progressCh := uploadProgress(path, ds)
+	defer close(progressCh)
+
+	f, err := os.Open(path)
 	if err != nil {
-		return err.Error()
+		return utils.NewDoerError(path, err)
 	}
 	defer f.Close()
 
@@ -109,26 +202,57 @@ func (s *fileScanner) Do(path interface{}, ds *utils.DoerState) string {
 		analysis, err = s.scanner.ScanFile(f, progressCh)
 	}
 	if err != nil {
-		return err.Error()
+		return utils.NewDoerError(path, err)
 	}
 
-	if s.showInVT {
+	return analysisResult(ctx, s.cli, path, analysis.ID(),
+		"file-analysis", s.showInVT, s.waitForCompletion, ds)
+}
+
+// uploadProgress returns a channel that receives the upload progress of a
+// file, as a percentage, and shows it in ds. The channel must be closed by
+// the caller when the upload finishes.
+func uploadProgress(path string, ds *utils.DoerState) chan float32 {
+	progressCh := make(chan float32)
+	go func() {
+		for progress := range progressCh {
+			if progress < 100 {
+				ds.Progress = fmt.Sprintf("%s uploading... %4.1f%%", path, progress)
+			} else {
+				ds.Progress = fmt.Sprintf("%s scanning...", path)
+			}
+		}
+	}()
+	return progressCh
+}
+
+// analysisResult returns the result for an item that was submitted for
+// analysis. If showInVT is true the result includes the analysis URL in the
+// VirusTotal web GUI, guiPath is the path for the URL. If wait is true the
+// function waits for the analysis to complete and includes the analysed object
+// in the result.
+func analysisResult(ctx context.Context, cli *utils.APIClient, item, analysisID, guiPath string, showInVT, wait bool, ds *utils.DoerState) *utils.DoerResult {
+	res := utils.NewDoerResult(item, "ok").With("analysis_id", analysisID)
+
+	if showInVT {
 		// Return the analysis URL in VT so users can visit it.
-		return fmt.Sprintf(
-			"%s https://www.virustotal.com/gui/file-analysis/%s",
-			path.(string), analysis.ID())
+		url := fmt.Sprintf("https://www.virustotal.com/gui/%s/%s", guiPath, analysisID)
+		res.With("url", url)
+		res.Text = fmt.Sprintf("%s %s", item, url)
+		return res
 	}
 
-	if s.waitForCompletion {
-		analysisResult, err := waitForAnalysisResults(s.cli, analysis.ID(), ds)
+	if wait {
+		obj, err := waitForAnalysisResults(ctx, cli, analysisID, ds)
 		if err != nil {
-			return err.Error()
+			return utils.NewDoerError(item, err).With("analysis_id", analysisID)
 		}
-		s.printer.PrintObject(analysisResult)
-		return ""
+		res.Object = obj
+		return res
 	}
 
-	return fmt.Sprintf("%s %s", path.(string), analysis.ID())
+	res.Text = fmt.Sprintf("%s %s", item, analysisID)
+	return res
 }
 
 var scanFileCmdHelp = `Scan one or more files.
@@ -142,12 +266,40 @@ analysis is completed.
 If the command receives a single hypen (-) the file paths are read from the standard
 input, one per line.
 
-The command can also receive a directory to scan all files contained on it.`
+The command can also receive directories, mixed with files or not, for
+scanning the files contained in them. Use --recursive for scanning the files in
+subdirectories too, --include-files and --exclude-files for choosing which
+files are scanned, and --max-size for skipping large files. Symbolic links found
+in directories are skipped unless --follow-symlinks is used. With --dedupe
+files are hashed before the upload and only one file among the ones with the
+same content is scanned. Before starting, the number of files to scan and
+their total size are printed to the standard error.
+
+Pressing Ctrl-C once stops starting new uploads and waits for the ones in
+progress, pressing it twice aborts them. In both cases a journal file with the
+completed, failed and pending files is written, use --resume with that file for
+retrying the failed files and continuing where the interrupted run stopped.
+
+With --skip-known each file is hashed and looked up in VirusTotal before
+uploading it, and only the files unknown to VirusTotal are uploaded. With
+--max-age known files whose last analysis is older than the given age, like 7d
+or 12h, are reanalysed instead of being uploaded again, this implies
+--skip-known. The result for each file says whether it was uploaded, reanalysed
+or skipped.
+
+With --wait and --fail-on the analysis results of each file are checked against
+a policy like 'malicious>=3 or suspicious>=5', a verdict line for each file is
+printed to the standard error, and the command exits with code 2 if any file
+matches the policy, with code 1 if some file couldn't be analysed, and with
+code 0 otherwise.`
 
 var scanFileCmdExample = `  vt scan file foo.exe
   vt scan file foo.exe bar.exe
-	vt scan file foo/
-  cat list_of_file_paths | vt scan file -`
+  vt scan file foo/
+  vt scan file --recursive --include-files '*.exe' --dedupe foo/ bar/ baz.dll
+  cat list_of_file_paths | vt scan file -
+  vt scan file --skip-known --max-age 7d samples/
+  vt scan file --wait --fail-on 'malicious>=3 or suspicious>=5' release/*.exe`
 
 // NewScanFileCmd returns a new instance of the 'scan file' command.
 func NewScanFileCmd() *cobra.Command {
@@ -156,23 +308,41 @@ func NewScanFileCmd() *cobra.Command {
 		Short:   "Scan one or more files",
 		Long:    scanFileCmdHelp,
 		Example: scanFileCmdExample,
-		Args:    cobra.MinimumNArgs(1),
+		Args:    minimumNArgsUnlessResume(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c := utils.NewCoordinator(viper.GetInt("threads"))
-			var argReader utils.StringReader
-			if len(args) == 1 && args[0] == "-" {
-				argReader = utils.NewStringIOReader(os.Stdin)
-			} else if len(args) == 1 && utils.IsDir(args[0]) {
-				argReader, _ = utils.NewFileDirReader(args[0])
-			} else {
-				argReader = utils.NewStringArrayReader(args)
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
 			}
-			client, err := NewAPIClient()
+			journal, argReader, err := openJournal(cmd)
 			if err != nil {
 				return err
 			}
-			p, err := NewPrinter(cmd)
+			// When resuming from a journal argReader already contains the
+			// pending files.
+			if argReader == nil {
+				var paths []string
+				r := utils.StringReaderFromCmdArgs(args)
+				for s, err := r.ReadString(); s != "" || err == nil; s, err = r.ReadString() {
+					paths = append(paths, s)
+				}
+				files, err := collectFiles(paths)
+				if err != nil {
+					return err
+				}
+				argReader = utils.NewStringArrayReader(files.Paths)
+			}
+			maxAge, err := utils.ParseDuration(viper.GetString("max-age"))
+			if err != nil {
+				return err
+			}
+			gate, err := newScanGate()
+			if err != nil {
+				return err
+			}
+			c.Gate = gate
+			client, err := NewAPIClient()
 			if err != nil {
 				return err
 			}
@@ -181,10 +351,13 @@ func NewScanFileCmd() *cobra.Command {
 				showInVT:          viper.GetBool("open"),
 				waitForCompletion: viper.GetBool("wait"),
 				password:          viper.GetString("password"),
-				printer:           p,
+				skipKnown:         viper.GetBool("skip-known"),
+				maxAge:            maxAge,
 				cli:               client}
-			c.DoWithStringsFromReader(s, argReader)
-			return nil
+			if err := doWithJournalFromReader(cmd, c, journal, s, argReader); err != nil {
+				return err
+			}
+			return gateError(cmd, gate)
 		},
 	}
 
@@ -193,6 +366,16 @@ func NewScanFileCmd() *cobra.Command {
 	addPasswordFlag(cmd.Flags())
 	addWaitForCompletionFlag(cmd.Flags())
 	addIncludeExcludeFlags(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
+	addWalkFlags(cmd.Flags(), false)
+	addDedupeFlag(cmd.Flags(), false)
+	cmd.Flags().Bool(
+		"skip-known", false,
+		"upload only the files that are unknown to VirusTotal")
+	cmd.Flags().String(
+		"max-age", "",
+		"reanalyse known files whose last analysis is older than this (e.g. 7d), instead of uploading them")
 	cmd.MarkZshCompPositionalArgumentFile(1)
 
 	return cmd
@@ -201,32 +384,18 @@ func NewScanFileCmd() *cobra.Command {
 type urlScanner struct {
 	scanner           *vt.URLScanner
 	cli               *utils.APIClient
-	printer           *utils.Printer
 	showInVT          bool
 	waitForCompletion bool
 }
 
-func (s *urlScanner) Do(url interface{}, ds *utils.DoerState) string {
+func (s *urlScanner) Do(ctx context.Context, url interface{}, ds *utils.DoerState) *utils.DoerResult {
 	analysis, err := s.scanner.Scan(url.(string))
 	if err != nil {
-		return err.Error()
+		return utils.NewDoerError(url.(string), err)
 	}
 
-	if s.showInVT {
-		return fmt.Sprintf(
-			"%s https://www.virustotal.com/gui/url-analysis/%s", url, analysis.ID())
-	}
-
-	if s.waitForCompletion {
-		analysisResult, err := waitForAnalysisResults(s.cli, analysis.ID(), ds)
-		if err != nil {
-			return err.Error()
-		}
-		s.printer.PrintObject(analysisResult)
-		return ""
-	}
-
-	return fmt.Sprintf("%s %s", url, analysis.ID())
+	return analysisResult(ctx, s.cli, url.(string), analysis.ID(),
+		"url-analysis", s.showInVT, s.waitForCompletion, ds)
 }
 
 var scanURLCmdHelp = `Scan one or more URLs.
@@ -237,7 +406,13 @@ retrieving information about the analyses or you can use the --wait
 flag to see the results when the analysis is completed.
 
 If the command receives a single hypen (-) the URLs are read from the standard
-input, one per line.`
+input, one per line.
+
+With --wait and --fail-on the analysis results of each URL are checked against
+a policy like 'malicious>=3 or suspicious>=5', a verdict line for each URL is
+printed to the standard error, and the command exits with code 2 if any URL
+matches the policy, with code 1 if some URL couldn't be analysed, and with code
+0 otherwise.`
 
 var scanURLCmdExample = `  vt scan url http://foo.com
   vt scan url http://foo.com http://bar.com
@@ -254,7 +429,15 @@ func NewScanURLCmd() *cobra.Command {
 		Args:    cobra.MinimumNArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c := utils.NewCoordinator(viper.GetInt("threads"))
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			gate, err := newScanGate()
+			if err != nil {
+				return err
+			}
+			c.Gate = gate
 			var argReader utils.StringReader
 			if len(args) == 1 && args[0] == "-" {
 				argReader = utils.NewStringIOReader(os.Stdin)
@@ -265,28 +448,34 @@ func NewScanURLCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			p, err := NewPrinter(cmd)
-			if err != nil {
-				return err
-			}
 			s := &urlScanner{
 				scanner:           client.NewURLScanner(),
 				showInVT:          viper.GetBool("open"),
 				waitForCompletion: viper.GetBool("wait"),
-				printer:           p,
 				cli:               client}
-			c.DoWithStringsFromReader(s, argReader)
-			return nil
+			c.DoWithStringsFromReader(cmd.Context(), s, argReader)
+			return gateError(cmd, gate)
 		},
 	}
 
 	addThreadsFlag(cmd.Flags())
 	addOpenInVTFlag(cmd.Flags())
 	addWaitForCompletionFlag(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
 
 	return cmd
 }
 
+// newScanGate works like newGate, but checks that --wait was used, as the
+// policy can be evaluated only when the analyses are completed.
+func newScanGate() (*utils.Gate, error) {
+	gate, err := newGate()
+	if err == nil && gate != nil && (!viper.GetBool("wait") || viper.GetBool("open")) {
+		return nil, errors.New("--fail-on requires --wait, and can't be used with --open")
+	}
+	return gate, err
+}
+
 var scanCmdHelp = `Scan files or URLs.
 
 This group of commands allow to scan files and URLs.`
@@ -302,6 +491,7 @@ func NewScanCmd() *cobra.Command {
 
 	cmd.AddCommand(NewScanURLCmd())
 	cmd.AddCommand(NewScanFileCmd())
+	cmd.AddCommand(NewScanWatchCmd())
 
 	return cmd
 }
@@ -316,6 +506,19 @@ func addWaitForCompletionFlag(flags *pflag.FlagSet) {
 	flags.BoolP(
 		"wait", "w", false,
 		"Wait until the analysis is completed and show the analysis results")
+	addPollFlags(flags)
+}
+
+func addPollFlags(flags *pflag.FlagSet) {
+	flags.Duration(
+		"poll-interval", DefaultPollInterval,
+		"initial interval between checks for the analysis completion")
+	flags.Duration(
+		"max-poll-interval", DefaultMaxPollInterval,
+		"maximum interval between checks, the interval grows after each check")
+	flags.Duration(
+		"wait-timeout", DefaultWaitTimeout,
+		"maximum time to wait for an analysis to complete")
 }
 
 func addPasswordFlag(flags *pflag.FlagSet) {
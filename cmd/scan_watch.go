@@ -0,0 +1,306 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Subdirectories of the watched directory where processed files are moved
+// with --move.
+const (
+	watchDoneDir    = "done"
+	watchFlaggedDir = "flagged"
+)
+
+// watchScanner is a Doer that scans the files found by "vt scan watch" with a
+// fileScanner, records the result for each file, and optionally moves the
+// file to a subdirectory according to its verdict.
+type watchScanner struct {
+	scanner *fileScanner
+	dir     string
+	// policy decides which files are flagged, it's used only when the
+	// analysis results are available.
+	policy  *utils.Policy
+	sidecar bool
+	move    bool
+
+	mu      sync.Mutex
+	results *os.File
+}
+
+func (w *watchScanner) Do(ctx context.Context, path interface{}, ds *utils.DoerState) *utils.DoerResult {
+	res := w.scanner.Do(ctx, path, ds)
+	record := res.ToMap()
+	delete(record, "object")
+	record["time"] = time.Now().UTC().Format(time.RFC3339)
+
+	verdict := ""
+	if res.Error == nil && res.Object != nil {
+		if stats, err := utils.AnalysisStats(res.Object); err == nil {
+			verdict = "clean"
+			if w.policy.Eval(stats) {
+				verdict = "flagged"
+			}
+			record["verdict"] = verdict
+			record["stats"] = stats
+		}
+	}
+
+	sidecarPath := path.(string)
+	if w.move && verdict != "" {
+		dest, err := w.moveFile(path.(string), verdict)
+		if err != nil {
+			utils.Errorf("moving %s: %v", path, err)
+		} else {
+			record["moved_to"] = dest
+			sidecarPath = dest
+		}
+	}
+
+	if err := w.record(record, sidecarPath); err != nil {
+		utils.Errorf("recording result for %s: %v", path, err)
+	}
+
+	// Print a line per file instead of the whole report, as the command can
+	// process a large number of files.
+	if res.Error == nil {
+		text := res.Text
+		if text == "" {
+			text = path.(string)
+		}
+		switch verdict {
+		case "clean":
+			text = fmt.Sprintf("%s [%s]", text, color.GreenString(verdict))
+		case "flagged":
+			text = fmt.Sprintf("%s [%s]", text, color.RedString(verdict))
+		}
+		if dest, ok := record["moved_to"]; ok {
+			text = fmt.Sprintf("%s -> %s", text, dest)
+		}
+		res.Text = text
+		res.Object = nil
+	}
+	return res
+}
+
+// moveFile moves a file to the "done" or "flagged" subdirectory of the
+// watched directory, depending on verdict. If a file with the same name
+// already exists there a numeric suffix is added to the name.
+func (w *watchScanner) moveFile(path, verdict string) (string, error) {
+	subdir := watchDoneDir
+	if verdict == "flagged" {
+		subdir = watchFlaggedDir
+	}
+	dir := filepath.Join(w.dir, subdir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	dest := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		dest = filepath.Join(dir, fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext))
+	}
+	return dest, os.Rename(path, dest)
+}
+
+// record writes the result record for a file to the results file, if any, and
+// to a sidecar file next to path if --sidecar was used.
+func (w *watchScanner) record(record map[string]interface{}, path string) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if w.sidecar {
+		if err := os.WriteFile(path+".vt.json", append(b, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	if w.results != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, err := w.results.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var scanWatchCmdHelp = `Watch a directory and scan the files put in it.
+
+This command watches a directory, like a drop folder where analysts or mail
+gateways deposit suspicious files, and scans the new or modified files. The
+directory and its subdirectories, unless --recursive=false is used, are
+checked every --interval, and files are scanned once they haven't changed
+during --settle, so that files are not uploaded while they are still being
+written. The command runs until it's interrupted with Ctrl-C.
+
+Files are uploaded like with "vt scan file", --skip-known and --max-age avoid
+uploading the files that VirusTotal already knows. A result record is written
+for each file, as a line in the JSON Lines file specified with --results, or as
+a sidecar file named like the scanned file with a .vt.json extension when
+--sidecar is used. As the command never finishes, --format can't be used,
+use --results for obtaining the results in a structured format.
+
+With --wait the analysis results are included in the records, and files are
+considered flagged if they match the --flag-on policy, like 'malicious>=3 or
+suspicious>=5'. With --move each file is moved after being scanned to the
+done/ or flagged/ subdirectory of the watched directory, depending on its
+verdict. The done/ and flagged/ subdirectories and the sidecar files are never
+scanned.`
+
+var scanWatchCmdExample = `  vt scan watch --results results.jsonl dropbox/
+  vt scan watch --skip-known --wait --move --sidecar dropbox/
+  vt scan watch --wait --move --flag-on 'malicious>=3' --include-files '*.exe' dropbox/`
+
+// NewScanWatchCmd returns a new instance of the 'scan watch' command.
+func NewScanWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "watch [dir]",
+		Short:   "Watch a directory and scan the files put in it",
+		Long:    scanWatchCmdHelp,
+		Example: scanWatchCmdExample,
+		Args:    cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+			if !utils.IsDir(dir) {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			// Results printed with --format are printed all together once
+			// the work finishes, which never happens here.
+			if viper.IsSet("format") {
+				return errors.New("--format can't be used with \"vt scan watch\", use --results for writing the results to a JSON Lines file")
+			}
+			if viper.GetDuration("interval") <= 0 {
+				return errors.New("--interval must be greater than 0")
+			}
+			if viper.GetDuration("settle") < 0 {
+				return errors.New("--settle can't be negative")
+			}
+			if viper.GetBool("move") && !viper.GetBool("wait") {
+				return errors.New("--move requires --wait, files are moved according to their analysis results")
+			}
+			policy, err := utils.ParsePolicy(viper.GetString("flag-on"))
+			if err != nil {
+				return err
+			}
+			maxAge, err := utils.ParseDuration(viper.GetString("max-age"))
+			if err != nil {
+				return err
+			}
+			w, err := newWalker()
+			if err != nil {
+				return err
+			}
+			w.Exclude = append(w.Exclude,
+				watchDoneDir+"/**", watchFlaggedDir+"/**", "*.vt.json")
+
+			c, err := NewCoordinator(cmd)
+			if err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			s := &watchScanner{
+				scanner: &fileScanner{
+					scanner:           client.NewFileScanner(),
+					waitForCompletion: viper.GetBool("wait"),
+					password:          viper.GetString("password"),
+					skipKnown:         viper.GetBool("skip-known"),
+					maxAge:            maxAge,
+					cli:               client},
+				dir:     dir,
+				policy:  policy,
+				sidecar: viper.GetBool("sidecar"),
+				move:    viper.GetBool("move"),
+			}
+			if filename := viper.GetString("results"); filename != "" {
+				f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				s.results = f
+			}
+
+			ctx, stop := utils.WithInterrupt(cmd.Context())
+			defer stop()
+
+			watcher := utils.NewDirWatcher(dir, w,
+				viper.GetDuration("interval"), viper.GetDuration("settle"))
+			ch := make(chan interface{})
+			watchErr := make(chan error, 1)
+			go func() {
+				watchErr <- watcher.Watch(ctx, ch)
+				close(ch)
+			}()
+			c.DoWithItemsFromChannel(ctx, s, ch)
+			return <-watchErr
+		},
+	}
+
+	addThreadsFlag(cmd.Flags())
+	addPasswordFlag(cmd.Flags())
+	addWaitForCompletionFlag(cmd.Flags())
+	addWalkFlags(cmd.Flags(), true)
+	cmd.Flags().Duration(
+		"interval", 5*time.Second,
+		"interval between checks for new files")
+	cmd.Flags().Duration(
+		"settle", 10*time.Second,
+		"time that a file must remain unchanged before scanning it")
+	cmd.Flags().Bool(
+		"skip-known", false,
+		"upload only the files that are unknown to VirusTotal")
+	cmd.Flags().String(
+		"max-age", "",
+		"reanalyse known files whose last analysis is older than this (e.g. 7d), instead of uploading them")
+	cmd.Flags().String(
+		"results", "",
+		"JSON Lines file where a result record is appended for each file")
+	cmd.Flags().Bool(
+		"sidecar", false,
+		"write the result record for each file to a .vt.json file next to it")
+	cmd.Flags().Bool(
+		"move", false,
+		"move scanned files to the done/ or flagged/ subdirectory, requires --wait")
+	cmd.Flags().String(
+		"flag-on", "malicious>=1",
+		"policy that decides which files are flagged (e.g. 'malicious>=3 or suspicious>=5')")
+	cmd.MarkZshCompPositionalArgumentFile(1)
+
+	return cmd
+}
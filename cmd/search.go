@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -33,7 +34,7 @@ func preRunSearchCmd(c *cobra.Command, args []string) error {
 			}
 		}
 	} else {
-		for _, flag := range []string{"output", "threads"} {
+		for _, flag := range []string{"output", "threads", "journal", "resume", "name-template", "layout", "manifest", "skip-existing", "store"} {
 			if c.Flag(flag).Changed {
 				return fmt.Errorf("--%s must be used with --download", flag)
 			}
@@ -45,6 +46,10 @@ func preRunSearchCmd(c *cobra.Command, args []string) error {
 
 func runSearchCmd(cmd *cobra.Command, args []string) error {
 
+	if viper.GetBool("download") && viper.GetString("resume") != "" {
+		return runSearchDownloadResume(cmd)
+	}
+
 	batchSize := 25
 	if viper.GetInt("limit") < batchSize {
 		batchSize = viper.GetInt("limit")
@@ -67,16 +72,24 @@ func runSearchCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	if viper.GetBool("download") {
-		ch := make(chan interface{})
-		go func() {
-			for it.Next() {
-				obj := it.Get()
-				ch <- obj.ID()
-			}
-			close(ch)
-		}()
-		c := utils.NewCoordinator(viper.GetInt("threads"))
-		c.DoWithItemsFromChannel(&downloader{newFileDownloader(client)}, ch)
+		c, err := NewCoordinator(cmd)
+		if err != nil {
+			return err
+		}
+		d, err := newDownloader(client)
+		if err != nil {
+			return err
+		}
+		err = doWithJournal(cmd, c, utils.NewJournal(cmd.CommandPath()),
+			func(ctx context.Context) {
+				c.DoWithObjectsFromIterator(ctx, d, it, 0)
+			})
+		if manifestErr := d.writeManifest(); err == nil {
+			err = manifestErr
+		}
+		if err != nil {
+			return err
+		}
 		return it.Error()
 	}
 
@@ -88,7 +101,41 @@ func runSearchCmd(cmd *cobra.Command, args []string) error {
 	return p.PrintIterator(it)
 }
 
-var cmdSearchHelp = `Search for files using VirusTotal Intelligence's query language.`
+// runSearchDownloadResume downloads the files that remained pending in the
+// journal of an interrupted "search --download".
+func runSearchDownloadResume(cmd *cobra.Command) error {
+	journal, hashes, err := openJournal(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
+	d, err := newDownloader(client)
+	if err != nil {
+		return err
+	}
+	err = doWithJournalFromReader(cmd, c, journal, d, hashes)
+	if manifestErr := d.writeManifest(); err == nil {
+		err = manifestErr
+	}
+	return err
+}
+
+var cmdSearchHelp = `Search for files using VirusTotal Intelligence's query language.
+
+When --download is used, pressing Ctrl-C once stops starting new downloads and
+waits for the ones in progress, pressing it twice aborts them. In both cases a
+journal file with the completed, failed and pending files is written. Use
+--resume with that file for downloading the failed and pending files, the query
+is not needed in that case. The search results that were not downloaded yet can
+be downloaded by running the same search with the --cursor printed when the
+search was interrupted.`
 
 var cmdSearchExample = `  vt search eicar
   vt search "foobar p:1+"`
@@ -97,7 +144,12 @@ var cmdSearchExample = `  vt search eicar
 func NewSearchCmd() *cobra.Command {
 
 	cmd := &cobra.Command{
-		Args:    cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("resume") {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Use:     "search [query]",
 		Short:   "Search for files in VirusTotal Intelligence",
 		Long:    cmdSearchHelp,
@@ -116,6 +168,9 @@ func NewSearchCmd() *cobra.Command {
 	addLimitFlag(cmd.Flags())
 	addCursorFlag(cmd.Flags())
 	addOutputFlag(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+	addDownloadLayoutFlags(cmd.Flags())
+	addDownloadStoreFlags(cmd.Flags())
 
 	cmd.AddCommand(NewContentSearchCmd())
 
@@ -127,8 +182,9 @@ type matchPrinter struct {
 	idOnly bool
 }
 
-func (m *matchPrinter) Do(fileObj interface{}, ds *utils.DoerState) string {
+func (m *matchPrinter) Do(ctx context.Context, fileObj interface{}, ds *utils.DoerState) *utils.DoerResult {
 	f := fileObj.(*vt.Object)
+	res := utils.NewDoerResult(f.ID(), "ok")
 	var line string
 	if m.idOnly {
 		line = f.ID()
@@ -140,17 +196,26 @@ func (m *matchPrinter) Do(fileObj interface{}, ds *utils.DoerState) string {
 		snippets := make([]string, 0)
 		_, err := m.client.GetData(vt.URL("intelligence/search/snippets/%s", snippetID), &snippets)
 		if err == nil {
+			// The snippets contain \x1c and \x1d for marking the start and end
+			// of the matching content, which are not included in the result.
+			clean := make([]string, len(snippets))
+			for i, snippet := range snippets {
+				clean[i] = strings.NewReplacer("\x1c", "", "\x1d", "").Replace(snippet)
+			}
+			res.With("snippets", clean)
 			s = strings.Join(snippets, "\n\n")
 			s = strings.Replace(s, "\x1c", "\033[1m", -1)
 			s = strings.Replace(s, "\x1d", "\033[0m", -1)
 		} else {
 			s = "<no snippet available>"
 		}
+		res.With("score", confidence).With("subfile", inSubFile)
 		line = fmt.Sprintf(
 			"%s\n\nsha256  : %s\nscore   : %03.1f \nsubfile : %v\n\n%s\n",
 			strings.Repeat("_", 76), f.ID(), confidence, inSubFile, s)
 	}
-	return line
+	res.Text = line
+	return res
 }
 
 func getIgnoredSubstrings(meta map[string]interface{}) []string {
@@ -193,17 +258,22 @@ func runContentSearchCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	c := utils.NewCoordinator(viper.GetInt("threads"))
+	c, err := NewCoordinator(cmd)
+	if err != nil {
+		return err
+	}
 
 	var doer utils.Doer
 	if viper.GetBool("download") {
-		doer = &downloader{newFileDownloader(client)}
+		if doer, err = newDownloader(client); err != nil {
+			return err
+		}
 	} else {
 		doer = &matchPrinter{client, viper.GetBool("identifiers-only")}
 		c.EnableSpinner()
 	}
 
-	c.DoWithObjectsFromIterator(doer, it, batchSize)
+	c.DoWithObjectsFromIterator(cmd.Context(), doer, it, batchSize)
 
 	if ignored := getIgnoredSubstrings(it.Meta()); ignored != nil {
 		colorScheme.CommentColor.Printf(
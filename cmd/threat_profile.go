@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/VirusTotal/vt-go"
 	"github.com/spf13/cobra"
@@ -187,7 +186,7 @@ func NewThreatProfileUpdateCmd() *cobra.Command {
 			updatedThreatProfile, err := client.GetObject(vt.URL("threat_profiles/%s", profileID))
 			if err != nil {
 				// If fetching the updated object fails, at least report the patch was successful
-				fmt.Fprintf(os.Stderr, "Warning: Failed to fetch updated threat profile details: %v\n", err)
+				utils.Warnf("Warning: Failed to fetch updated threat profile details: %v", err)
 				fmt.Printf("Threat profile %s updated successfully.\n", profileID)
 				return nil
 			}
@@ -28,17 +28,47 @@ about each URL is returned in the same order as the URLs are passed to the
 command.
 
 If the command receives a single hypen (-) the URLs are read from the standard
-input, one per line.
+input, one per line. With --extract the URLs are extracted from free text,
+like reports or logs, and defanged URLs like hxxp://example[.]com are refanged.
+
+With --input-format csv, tsv or jsonl the command receives a single file, or a
+hypen (-) for reading from the standard input, and the URLs are read from the
+field specified with --input-field. For CSV and TSV the field is either a column
+number starting at 1 or a column name, for JSON Lines it is a path like src.ip.
+Use --with-input for including in the output the record from which each URL
+was read, as an "_input" field.
+
+With --fail-on the last_analysis_stats of each URL is checked against a policy
+like 'malicious>=3 or suspicious>=5', a verdict line for each URL is printed to
+the standard error, and the command exits with code 2 if any URL matches the
+policy, with code 1 if some URL couldn't be checked, and with code 0 otherwise.
 `
 
 var urlCmdExample = `  vt url https://www.virustotal.com
   vt url f1177df4692356280844e1d5af67cc4a9eccecf77aa61c229d483b7082c70a8e
-  cat list_of_urls | vt url -`
+  cat list_of_urls | vt url -
+  cat incident_report.txt | vt url --extract -
+  vt url --input-format jsonl --input-field http.url --with-input proxy_logs.jsonl`
 
 
 // Regular expressions used for validating a URL identifier.
 var urlID = regexp.MustCompile(`[0-9a-fA-F]{64}`)
 
+// urlObjectID returns the identifier of the URL object corresponding to url,
+// which is the URL encoded as base64. If url is already an identifier it's
+// returned as is.
+func urlObjectID(url string) string {
+	if urlID.MatchString(url) {
+		// The user provided a URL identifier as returned by VirusTotal's API,
+		// which consists in the URL's SHA-256. In that case use the
+		// identifier as is.
+		return url
+	}
+	// If the user provides an actual URL, it needs to be encoded as base64
+	// before being used.
+	return base64.RawURLEncoding.EncodeToString([]byte(url))
+}
+
 // NewURLCmd returns a new instance of the 'url' command.
 func NewURLCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -49,32 +79,37 @@ func NewURLCmd() *cobra.Command {
 		Args:    cobra.MinimumNArgs(1),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			in, closeFn, err := inputReaderFromCmdArgs(args, utils.IOCURL)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+			gate, err := newGate()
+			if err != nil {
+				return err
+			}
 			p, err := NewPrinter(cmd)
 			if err != nil {
 				return err
 			}
-			r := utils.NewMappedStringReader(
-				utils.StringReaderFromCmdArgs(args),
-				func (url string) string {
-					if urlID.MatchString(url) {
-						// The user provided a URL identifier as returned by
-						// VirusTotal's API, which consists in the URL's SHA-256.
-						// In that case use the identifier as is.
-						return url
-					}
-					// If the user provides an actual URL, it needs to be
-					// encoded as base64 before being used.
-					return base64.RawURLEncoding.EncodeToString([]byte(url))
-				})
-			return p.GetAndPrintObjects("urls/%s", r, nil)
+			p.Gate = gate
+			r := utils.NewMappedStringReader(in, urlObjectID)
+			if err := p.GetAndPrintObjects("urls/%s", r, nil); err != nil {
+				return err
+			}
+			return gateError(cmd, gate)
 		},
 	}
 
+	cmd.AddCommand(NewReanalyzeCmd("urls"))
 	addRelationshipCmds(cmd, "urls", "url", "[url]")
 
 	addThreadsFlag(cmd.Flags())
 	addIncludeExcludeFlags(cmd.Flags())
 	addIDOnlyFlag(cmd.Flags())
+	addExtractFlag(cmd.Flags())
+	addInputFlags(cmd.Flags())
+	addFailOnFlag(cmd.Flags())
 
 	return cmd
 }
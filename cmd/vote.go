@@ -0,0 +1,109 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// voter is a Doer that casts the same vote for multiple objects.
+type voter struct {
+	cli        *utils.APIClient
+	objectType string
+	verdict    string
+}
+
+func (v *voter) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	s := item.(string)
+	path, err := objectPath(v.objectType, s)
+	if err != nil {
+		return utils.NewDoerError(s, err)
+	}
+	ds.Progress = fmt.Sprintf("%s voting...", s)
+	vote := vt.NewObject("vote")
+	vote.SetString("verdict", v.verdict)
+	err = v.cli.PostObject(vt.URL("%s/votes", path), vote)
+	return apiResult(s, err).With("verdict", v.verdict)
+}
+
+// NewVoteCmd returns a new instance of the 'vote' command.
+func NewVoteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vote",
+		Short: "Vote for files, URLs, domains and IP addresses",
+	}
+
+	cmd.AddCommand(NewVoteAddCmd())
+
+	return cmd
+}
+
+var voteAddCmdHelp = `Vote for one or more objects.
+
+This command casts a vote with the verdict specified with --verdict, either
+malicious or harmless, for one or more files, URLs, domains or IP addresses.
+The type of each object is detected automatically, use --type when it's
+ambiguous, for example for using URL identifiers instead of URLs.
+
+If the command receives a single hypen (-) the objects are read from the
+standard input, one per line. Use --journal and --resume for continuing an
+interrupted run, as with "vt download".`
+
+var voteAddCmdExample = `  vt vote add --verdict malicious 44d88612fea8a8f36de82e1278abb02f evil.example.com
+  cat iocs.txt | vt vote add --verdict malicious -`
+
+// NewVoteAddCmd returns a new instance of the 'vote add' command.
+func NewVoteAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "add [object]...",
+		Short:   "Vote for files, URLs, domains or IP addresses",
+		Long:    voteAddCmdHelp,
+		Example: voteAddCmdExample,
+		Args:    minimumNArgsUnlessResume(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verdict := viper.GetString("verdict")
+			if verdict != "malicious" && verdict != "harmless" {
+				return fmt.Errorf("invalid verdict %q, use malicious or harmless", verdict)
+			}
+			objectType := viper.GetString("type")
+			if err := checkObjectType(objectType); err != nil {
+				return err
+			}
+			client, err := NewAPIClient()
+			if err != nil {
+				return err
+			}
+			return runWithJournal(cmd, args, &voter{
+				cli:        client,
+				objectType: objectType,
+				verdict:    verdict,
+			})
+		},
+	}
+
+	cmd.Flags().String("verdict", "", "verdict of the vote: malicious or harmless (required)")
+	_ = cmd.MarkFlagRequired("verdict")
+	addObjectTypeFlag(cmd.Flags())
+	addThreadsFlag(cmd.Flags())
+	addJournalFlags(cmd.Flags())
+
+	return cmd
+}
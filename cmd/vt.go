@@ -15,8 +15,10 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"net/url"
+	"strings"
 
+	"github.com/VirusTotal/vt-cli/utils"
 	vt "github.com/VirusTotal/vt-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -37,22 +39,42 @@ func NewVTCommand() *cobra.Command {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				return err
 			}
+			if err := applyProfile(cmd, viper.GetString("profile")); err != nil {
+				return err
+			}
+			if err := setupLogging(); err != nil {
+				return err
+			}
 			host := viper.GetString("host")
 			if host != "" {
 				vt.SetHost(host)
 			}
-			proxy := viper.GetString("proxy")
-			if proxy != "" {
-				os.Setenv("http_proxy", proxy)
-			}
 			if viper.GetBool("verbose") {
 				if configFile := viper.ConfigFileUsed(); configFile != "" {
-					fmt.Fprintf(os.Stderr, "* Config file: %s\n", configFile)
+					utils.Infof("* Config file: %s", configFile)
 				}
-				if apiKey := viper.GetString("apikey"); apiKey != "" {
-					fmt.Fprintf(os.Stderr, "* API key: %s\n", apiKey)
+				if profile := viper.GetString("profile"); profile != "" {
+					utils.Infof("* Profile: %s", profile)
+				}
+				if apiKey := viper.GetString("apikey"); len(apiKey) > 8 {
+					// Show only the first characters, the full API key
+					// never appears in the logs.
+					utils.Infof("* API key: %s...", apiKey[:4])
+				}
+				utils.Infof("* API host: %s", host)
+				if proxy := viper.GetString("proxy"); proxy != "" {
+					utils.Infof("* Proxy: %s", utils.RedactedProxy(proxy))
+				}
+				if caFile := viper.GetString("ca-file"); caFile != "" {
+					if _, n, err := utils.LoadCAFile(caFile); err == nil {
+						utils.Infof("* Trust roots: system + %d certificate(s) from %s", n, caFile)
+					}
+				} else {
+					utils.Infof("* Trust roots: system")
+				}
+				if clientCert := viper.GetString("client-cert"); clientCert != "" {
+					utils.Infof("* Client certificate: %s", clientCert)
 				}
-				fmt.Fprintf(os.Stderr, "* API host: %s\n", host)
 			}
 			return nil
 		},
@@ -61,15 +83,22 @@ func NewVTCommand() *cobra.Command {
 	addAPIKeyFlag(cmd.PersistentFlags())
 	addFormatFlag(cmd.PersistentFlags())
 	addHostFlag(cmd.PersistentFlags())
-	addProxyFlag(cmd.PersistentFlags())
+	addProfileFlag(cmd.PersistentFlags())
+	addProxyFlags(cmd.PersistentFlags())
 	addSilentFlag(cmd.PersistentFlags())
+	addTimeoutFlag(cmd.PersistentFlags())
+	addTLSFlags(cmd.PersistentFlags())
 	addVerboseFlag(cmd.PersistentFlags())
+	addTraceFlags(cmd.PersistentFlags())
 
 	cmd.AddCommand(NewAnalysisCmd())
 	cmd.AddCommand(NewCollectionCmd())
+	cmd.AddCommand(NewCommentCmd())
 	cmd.AddCommand(NewCompletionCmd())
 	cmd.AddCommand(NewDomainCmd())
 	cmd.AddCommand(NewDownloadCmd())
+	cmd.AddCommand(NewEnrichCmd())
+	cmd.AddCommand(NewExtractCmd())
 	cmd.AddCommand(NewFileCmd())
 	cmd.AddCommand(NewGenDocCmd())
 	cmd.AddCommand(NewGroupCmd())
@@ -77,16 +106,64 @@ func NewVTCommand() *cobra.Command {
 	cmd.AddCommand(NewIOCStreamCmd())
 	cmd.AddCommand(NewInitCmd())
 	cmd.AddCommand(NewIPCmd())
+	cmd.AddCommand(NewLookupCmd())
 	cmd.AddCommand(NewMetaCmd())
+	cmd.AddCommand(NewPivotCmd())
+	cmd.AddCommand(NewPrivateCmd())
 	cmd.AddCommand(NewRetrohuntCmd())
 	cmd.AddCommand(NewScanCmd())
 	cmd.AddCommand(NewSearchCmd())
 	cmd.AddCommand(NewURLCmd())
 	cmd.AddCommand(NewUserCmd())
 	cmd.AddCommand(NewVersionCmd())
+	cmd.AddCommand(NewVoteCmd())
 	cmd.AddCommand(NewMonitorCmd())
 	cmd.AddCommand(NewMonitorPartnerCmd())
 	cmd.AddCommand(NewThreatProfileCmd())
 
 	return cmd
 }
+
+// setupLogging configures the logger according to the --verbose, --trace and
+// --log-file flags.
+func setupLogging() error {
+	level := utils.LogWarning
+	if viper.GetBool("trace") {
+		level = utils.LogDebug
+	} else if viper.GetBool("verbose") {
+		level = utils.LogInfo
+	}
+	if err := utils.SetupLogging(level, viper.GetString("log-file")); err != nil {
+		return err
+	}
+	utils.Redact(viper.GetString("apikey"))
+	if _, password, ok := strings.Cut(viper.GetString("proxy-user"), ":"); ok {
+		utils.Redact(password)
+	}
+	if u, err := url.Parse(viper.GetString("proxy")); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			utils.Redact(password)
+		}
+	}
+	return nil
+}
+
+// applyProfile overrides the settings in the config file with the ones in the
+// [profiles.<name>] section. Settings explicitly passed in the command line
+// still take precedence over the ones in the profile.
+func applyProfile(cmd *cobra.Command, name string) error {
+	if name == "" {
+		return nil
+	}
+	profile := viper.Sub("profiles." + name)
+	if profile == nil {
+		return fmt.Errorf("profile %q not found in config file", name)
+	}
+	for _, key := range profile.AllKeys() {
+		if f := cmd.Flags().Lookup(key); f != nil && f.Changed {
+			continue
+		}
+		viper.Set(key, profile.Get(key))
+	}
+	return nil
+}
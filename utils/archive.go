@@ -0,0 +1,452 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// maxArchiveDepth is the maximum nesting level of the archives opened by
+	// WalkArchive.
+	maxArchiveDepth = 8
+	// maxNestedArchiveSize is the maximum size of an archive contained in
+	// another archive for it to be opened by WalkArchive. Nested archives are
+	// loaded in memory.
+	maxNestedArchiveSize = 256 * 1024 * 1024
+)
+
+// ErrUnsupportedEncryption is returned when a ZIP member is encrypted with a
+// method other than the traditional PKWARE encryption.
+var ErrUnsupportedEncryption = errors.New("unsupported ZIP encryption, only traditional PKWARE encryption is supported")
+
+// ErrPasswordRequired is returned when a ZIP member is encrypted and no
+// password was given.
+var ErrPasswordRequired = errors.New("encrypted file, a password is required")
+
+// ErrWrongPassword is returned when a ZIP member can't be decrypted with the
+// given password.
+var ErrWrongPassword = errors.New("wrong password")
+
+// ArchiveMemberPath returns the path used for referring to a member of an
+// archive, like "sample.zip!/payload/a.exe".
+func ArchiveMemberPath(archive, member string) string {
+	return archive + "!/" + strings.TrimLeft(member, "/")
+}
+
+// archiveFormat returns the format of the archive starting with the given
+// bytes: "zip", "gzip" or "tar", or an empty string if it is not an archive.
+func archiveFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return "zip"
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return "gzip"
+	case len(header) >= 262 && bytes.Equal(header[257:262], []byte("ustar")):
+		return "tar"
+	}
+	return ""
+}
+
+// WalkArchive calls fn for each file contained in the ZIP, TAR, tar.gz or gzip
+// archive at the given path, with the member's path as returned by
+// ArchiveMemberPath and a reader for its content. Archives contained in the
+// archive are passed to fn and then opened recursively. Encrypted ZIP members
+// are decrypted with password. Members that can't be read are reported with a
+// warning and skipped. If the file is not an archive fn is not called.
+func WalkArchive(filename, password string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return walkArchive(filename, f, info.Size(), password, 0, fn)
+}
+
+// ReadZip calls fn for each file contained in the ZIP archive at the given
+// path, with the member's name and a reader for its content. Unlike
+// WalkArchive, archives contained in the archive are not opened. Encrypted
+// members are decrypted with password. If a member can't be read the error is
+// returned.
+func ReadZip(filename, password string, fn func(name string, r io.Reader) error) error {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		r, err := openZipFile(f, password)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		err = fn(f.Name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkArchive(name string, ra io.ReaderAt, size int64, password string, depth int, fn func(string, io.Reader) error) error {
+	header := make([]byte, 512)
+	n, _ := ra.ReadAt(header, 0)
+	switch archiveFormat(header[:n]) {
+	case "zip":
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			Warnf("skipping %s: %v", name, err)
+			return nil
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			member := ArchiveMemberPath(name, f.Name)
+			r, err := openZipFile(f, password)
+			if err != nil {
+				Warnf("skipping %s: %v", member, err)
+				continue
+			}
+			err = walkMember(member, r, password, depth, fn)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+	case "gzip":
+		gz, err := gzip.NewReader(io.NewSectionReader(ra, 0, size))
+		if err != nil {
+			Warnf("skipping %s: %v", name, err)
+			return nil
+		}
+		defer gz.Close()
+		// The members of a tar.gz archive are treated as members of the
+		// archive itself, as in sample.tgz!/payload/a.exe.
+		br := bufio.NewReaderSize(gz, 512)
+		if header, _ := br.Peek(512); archiveFormat(header) == "tar" {
+			return walkTar(name, br, password, depth, fn)
+		}
+		member := gz.Name
+		if member == "" {
+			member = strings.TrimSuffix(path.Base(name), ".gz")
+		}
+		return walkMember(ArchiveMemberPath(name, member), br, password, depth, fn)
+	case "tar":
+		return walkTar(name, io.NewSectionReader(ra, 0, size), password, depth, fn)
+	}
+	return nil
+}
+
+func walkTar(name string, r io.Reader, password string, depth int, fn func(string, io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			Warnf("skipping rest of %s: %v", name, err)
+			return nil
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := walkMember(ArchiveMemberPath(name, h.Name), tr, password, depth, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// walkMember calls fn for an archive member. If the member is an archive
+// itself, and it's not too large or too deeply nested, it is opened too.
+func walkMember(name string, r io.Reader, password string, depth int, fn func(string, io.Reader) error) error {
+	br := bufio.NewReaderSize(r, 512)
+	header, _ := br.Peek(512)
+	if archiveFormat(header) == "" || depth+1 >= maxArchiveDepth {
+		return readMember(name, br, fn)
+	}
+	data, err := io.ReadAll(io.LimitReader(br, maxNestedArchiveSize+1))
+	if err != nil {
+		Warnf("skipping %s: %v", name, err)
+		return nil
+	}
+	if len(data) > maxNestedArchiveSize {
+		Warnf("not opening %s: nested archive is too large", name)
+		return readMember(name, io.MultiReader(bytes.NewReader(data), br), fn)
+	}
+	if err := fn(name, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return walkArchive(name, bytes.NewReader(data), int64(len(data)), password, depth+1, fn)
+}
+
+// readMember calls fn for an archive member that is not opened as an archive.
+// If reading the member fails, the error is reported as a warning.
+func readMember(name string, r io.Reader, fn func(string, io.Reader) error) error {
+	er := &errorReader{r: r}
+	if err := fn(name, er); err != nil && er.err == nil {
+		return err
+	}
+	if er.err != nil {
+		Warnf("skipping %s: %v", name, er.err)
+	}
+	return nil
+}
+
+// errorReader records the first error other than io.EOF returned by the
+// underlying reader, so that errors produced while reading archive members
+// can be distinguished from the ones returned by the function consuming them.
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errorReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// openZipFile opens a file in a ZIP archive, decrypting it with password if
+// it is encrypted.
+func openZipFile(f *zip.File, password string) (io.ReadCloser, error) {
+	if f.Flags&0x1 == 0 {
+		return f.Open()
+	}
+	if f.Method == 99 {
+		return nil, ErrUnsupportedEncryption
+	}
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	// The check byte is the most significant byte of the CRC, or of the
+	// modification time if the CRC is stored after the data.
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&0x8 != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	dr, err := newZipCryptoReader(raw, password, check)
+	if err != nil {
+		return nil, err
+	}
+	var rc io.ReadCloser
+	switch f.Method {
+	case zip.Store:
+		rc = io.NopCloser(dr)
+	case zip.Deflate:
+		rc = flate.NewReader(dr)
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+	return &crcReader{rc: rc, h: crc32.NewIEEE(), crc: f.CRC32}, nil
+}
+
+// crcReader checks that the CRC32 of the data read matches the expected one.
+type crcReader struct {
+	rc  io.ReadCloser
+	h   hash.Hash32
+	crc uint32
+}
+
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.h.Write(p[:n])
+	if err == io.EOF && c.h.Sum32() != c.crc {
+		return n, ErrWrongPassword
+	}
+	return n, err
+}
+
+func (c *crcReader) Close() error {
+	return c.rc.Close()
+}
+
+// zipCryptoKeys are the keys used by the traditional PKWARE encryption,
+// described in section 6.1 of the ZIP specification.
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^b] ^ (k[0] >> 8)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ (k[2] >> 8)
+}
+
+// stream returns the next byte of the key stream.
+func (k *zipCryptoKeys) stream() byte {
+	t := uint16(k[2] | 2)
+	return byte((t * (t ^ 1)) >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(p []byte) {
+	for i := range p {
+		p[i] ^= k.stream()
+		k.update(p[i])
+	}
+}
+
+func (k *zipCryptoKeys) encrypt(p []byte) {
+	for i := range p {
+		c := p[i] ^ k.stream()
+		k.update(p[i])
+		p[i] = c
+	}
+}
+
+// zipCryptoReader decrypts data encrypted with the traditional PKWARE
+// encryption.
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func newZipCryptoReader(r io.Reader, password string, check byte) (*zipCryptoReader, error) {
+	z := &zipCryptoReader{r: r, keys: newZipCryptoKeys(password)}
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	z.keys.decrypt(header)
+	if header[11] != check {
+		return nil, ErrWrongPassword
+	}
+	return z, nil
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.keys.decrypt(p[:n])
+	return n, err
+}
+
+// zipCryptoWriter encrypts data with the traditional PKWARE encryption.
+type zipCryptoWriter struct {
+	w    io.Writer
+	keys *zipCryptoKeys
+	buf  []byte
+}
+
+func newZipCryptoWriter(w io.Writer, password string, check byte) (*zipCryptoWriter, error) {
+	z := &zipCryptoWriter{w: w, keys: newZipCryptoKeys(password)}
+	header := make([]byte, 12)
+	if _, err := rand.Read(header[:11]); err != nil {
+		return nil, err
+	}
+	header[11] = check
+	if _, err := z.Write(header); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (z *zipCryptoWriter) Write(p []byte) (int, error) {
+	// p can't be modified, the data is encrypted in a separate buffer.
+	z.buf = append(z.buf[:0], p...)
+	z.keys.encrypt(z.buf)
+	return z.w.Write(z.buf)
+}
+
+// WriteEncryptedZip writes to w a ZIP archive containing a single file with
+// the given name and the data read from r, compressed and encrypted with the
+// given password using the traditional PKWARE encryption. It's the encryption
+// supported by most tools, and the one usually used for sharing malware
+// samples, for which it's enough for preventing them from being opened by
+// accident. It's not a secure encryption.
+func WriteEncryptedZip(w io.Writer, name string, r io.Reader, password string) error {
+	zw := zip.NewWriter(w)
+	// The CRC and sizes are not known until all the data is written, so they
+	// are stored after the data, and the encryption header is checked
+	// against the modification time instead of the CRC.
+	fh := &zip.FileHeader{Name: name, Method: zip.Deflate, Flags: 0x1 | 0x8}
+	fh.ModifiedDate, fh.ModifiedTime = msDosTime(time.Now())
+	fw, err := zw.CreateRaw(fh)
+	if err != nil {
+		return err
+	}
+	encrypted := &countWriter{w: fw}
+	ew, err := newZipCryptoWriter(encrypted, password, byte(fh.ModifiedTime>>8))
+	if err != nil {
+		return err
+	}
+	fl, err := flate.NewWriter(ew, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	crc := crc32.NewIEEE()
+	n, err := io.Copy(io.MultiWriter(fl, crc), r)
+	if err != nil {
+		return err
+	}
+	if err := fl.Close(); err != nil {
+		return err
+	}
+	fh.CRC32 = crc.Sum32()
+	fh.UncompressedSize64 = uint64(n)
+	fh.CompressedSize64 = uint64(encrypted.n)
+	return zw.Close()
+}
+
+// msDosTime returns the MS-DOS date and time corresponding to t.
+func msDosTime(t time.Time) (uint16, uint16) {
+	date := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	tm := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, tm
+}
+
+// countWriter counts the bytes written to the underlying io.Writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,152 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// encryptedZip is a ZIP archive with a file a.txt containing "hello\n",
+// encrypted with the traditional PKWARE encryption and password "infected".
+const encryptedZip = `
+UEsDBAoACQAAADwsU10gMDo2EgAAAAYAAAAFAAAAYS50eHTlftwHL9+hsl3nGJooLjISfjtQSwcI
+IDA6NhIAAAAGAAAAUEsBAh4DCgAJAAAAPCxTXSAwOjYSAAAABgAAAAUAAAAAAAAAAQAAAKSBAAAA
+AGEudHh0UEsFBgAAAAABAAEAMwAAAEUAAAAAAA==`
+
+func makeZip(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write(content)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func makeTarGz(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		tw.Write(content)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func walkArchive(t *testing.T, path, password string) map[string]string {
+	members := make(map[string]string)
+	err := utils.WalkArchive(path, password, func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(filepath.Dir(path), name)
+		members[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	assert.NoError(t, err)
+	return members
+}
+
+func TestWalkArchive(t *testing.T) {
+	dir := t.TempDir()
+	tgz := makeTarGz(t, map[string][]byte{
+		"payload/a.exe": []byte("MZ"),
+		"payload/b.dll": []byte("MZMZ"),
+	})
+	nested := makeZip(t, map[string][]byte{
+		"readme.txt":     []byte("readme"),
+		"inner/t.tar.gz": tgz,
+	})
+	gzBuf := &bytes.Buffer{}
+	gz := gzip.NewWriter(gzBuf)
+	gz.Write([]byte("log line"))
+	gz.Close()
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, data, 0o644))
+		return path
+	}
+
+	assert.Equal(t, map[string]string{
+		"sample.zip!/readme.txt":                    "readme",
+		"sample.zip!/inner/t.tar.gz":                string(tgz),
+		"sample.zip!/inner/t.tar.gz!/payload/a.exe": "MZ",
+		"sample.zip!/inner/t.tar.gz!/payload/b.dll": "MZMZ",
+	}, walkArchive(t, write("sample.zip", nested), ""))
+
+	assert.Equal(t, map[string]string{"app.log.gz!/app.log": "log line"},
+		walkArchive(t, write("app.log.gz", gzBuf.Bytes()), ""))
+
+	assert.Empty(t, walkArchive(t, write("plain.txt", []byte("not an archive")), ""))
+
+	enc, err := base64.StdEncoding.DecodeString(encryptedZip)
+	assert.NoError(t, err)
+	path := write("enc.zip", enc)
+	assert.Equal(t, map[string]string{"enc.zip!/a.txt": "hello\n"}, walkArchive(t, path, "infected"))
+	// Members that can't be decrypted are skipped.
+	assert.Empty(t, walkArchive(t, path, "wrong"))
+	assert.Empty(t, walkArchive(t, path, ""))
+}
+
+func TestReadZip(t *testing.T) {
+	dir := t.TempDir()
+	inner := makeZip(t, map[string][]byte{"inner.txt": []byte("inner")})
+	plain := filepath.Join(dir, "plain.zip")
+	assert.NoError(t, os.WriteFile(plain,
+		makeZip(t, map[string][]byte{"a.txt": []byte("a"), "nested.zip": inner}), 0o644))
+
+	// Nested archives are returned as they are, not opened.
+	members := make(map[string]string)
+	assert.NoError(t, utils.ReadZip(plain, "", func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		members[name] = string(b)
+		return err
+	}))
+	assert.Equal(t, map[string]string{"a.txt": "a", "nested.zip": string(inner)}, members)
+
+	data, err := base64.StdEncoding.DecodeString(encryptedZip)
+	assert.NoError(t, err)
+	encrypted := filepath.Join(dir, "encrypted.zip")
+	assert.NoError(t, os.WriteFile(encrypted, data, 0o644))
+
+	members = make(map[string]string)
+	assert.NoError(t, utils.ReadZip(encrypted, "infected", func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		members[name] = string(b)
+		return err
+	}))
+	assert.Equal(t, map[string]string{"a.txt": "hello\n"}, members)
+
+	err = utils.ReadZip(encrypted, "", func(name string, r io.Reader) error { return nil })
+	assert.ErrorIs(t, err, utils.ErrPasswordRequired)
+}
@@ -15,9 +15,11 @@ package utils
 
 import (
 	"container/heap"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"net/http"
 	"sync"
 
 	vt "github.com/VirusTotal/vt-go"
@@ -27,6 +29,10 @@ import (
 // APIClient represents a VirusTotal API client.
 type APIClient struct {
 	*vt.Client
+	// HTTPClient is the http.Client used by the API client. It can be shared
+	// with other components that need to talk with VirusTotal, like the file
+	// downloader, so that they use the same network settings.
+	HTTPClient *http.Client
 }
 
 // NewAPIClient returns a new VirusTotal API client using the API key configured
@@ -37,20 +43,45 @@ func NewAPIClient(agent string) (*APIClient, error) {
 		return nil, errors.New(
 			"An API key is needed. Either use the --apikey flag or run \"vt init\" to set up your API key")
 	}
-	c := vt.NewClient(apikey)
+	httpClient, err := NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	c := vt.NewClient(apikey, vt.WithHTTPClient(httpClient))
 	c.Agent = agent
-	return &APIClient{c}, nil
+	return &APIClient{Client: c, HTTPClient: httpClient}, nil
 }
 
 // RetrieveObjects retrieves objects from the specified endpoint. The endpoint
 // must contain a %s placeholder that will be replaced with items from the args
-// slice. The objects are put into the outCh as they are retrieved.
-func (c *APIClient) RetrieveObjects(endpoint string, args []string, outCh chan *vt.Object, errCh chan error) error {
+// slice. The objects are put into the outCh as they are retrieved. When ctx
+// starts draining (see Draining) no new requests are sent, and the objects
+// that were not retrieved are reported as ctx.Err() in errCh.
+func (c *APIClient) RetrieveObjects(ctx context.Context, endpoint string, args []string, outCh chan *vt.Object, errCh chan error) error {
 
 	// Make sure outCh and errCh are closed
 	defer close(outCh)
 	defer close(errCh)
 
+	c.RetrieveObjectsFunc(ctx, endpoint, args, func(i int, obj *vt.Object, err error) {
+		if err != nil {
+			errCh <- err
+		} else {
+			outCh <- obj
+		}
+	})
+
+	return nil
+}
+
+// RetrieveObjectsFunc works like RetrieveObjects, but instead of sending the
+// objects and errors to channels it calls fn with the index in args of the
+// item that was retrieved, and either the retrieved object or the error. fn is
+// called in the same order as the items appear in args, and never concurrently.
+// Errors, including "not found" errors, don't interrupt the retrieval of the
+// remaining items.
+func (c *APIClient) RetrieveObjectsFunc(ctx context.Context, endpoint string, args []string, fn func(int, *vt.Object, error)) {
+
 	h := PQueue{}
 	heap.Init(&h)
 
@@ -65,6 +96,7 @@ func (c *APIClient) RetrieveObjects(endpoint string, args []string, outCh chan *
 	}
 
 	throttler := make(chan interface{}, threads)
+	draining := Draining(ctx)
 
 	// Read object IDs from the input channel, launch goroutines to retrieve the
 	// objects and send them through objCh together with a number indicating
@@ -74,17 +106,17 @@ func (c *APIClient) RetrieveObjects(endpoint string, args []string, outCh chan *
 	for order, arg := range args {
 		getWg.Add(1)
 		go func(order int, arg string) {
-			throttler <- nil
-			obj, err := c.GetObject(vt.URL(endpoint, arg))
-			if err == nil {
+			select {
+			case throttler <- nil:
+			case <-draining:
+				objCh <- PQueueNode{Priority: order, Data: fmt.Errorf("%s: %v", arg, context.Canceled)}
+				getWg.Done()
+				return
+			}
+			if obj, err := c.GetObject(vt.URL(endpoint, arg)); err == nil {
 				objCh <- PQueueNode{Priority: order, Data: obj}
 			} else {
-				if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
-					objCh <- PQueueNode{Priority: order, Data: err}
-				} else {
-					fmt.Fprintln(os.Stderr, err)
-					os.Exit(1)
-				}
+				objCh <- PQueueNode{Priority: order, Data: err}
 			}
 			getWg.Done()
 			<-throttler
@@ -94,33 +126,23 @@ func (c *APIClient) RetrieveObjects(endpoint string, args []string, outCh chan *
 	outWg := &sync.WaitGroup{}
 	outWg.Add(1)
 
-	// Read objects from objCh, put them into a priority queue and send them in
-	// their original order to outCh.
+	// Read objects from objCh, put them into a priority queue and pass them to
+	// fn in their original order.
 	go func() {
 		order := 0
 		for p := range objCh {
 			heap.Push(&h, p)
-			// If the object in the top of the queue is the next one in the order
-			// it can be sent to outCh and removed from the queue, if not, we keep
-			// pushing objects into the queue.
-			if h[0].Priority == order {
-				if obj, ok := h[0].Data.(*vt.Object); ok {
-					outCh <- obj
-				} else {
-					errCh <- h[0].Data.(error)
-				}
-				heap.Pop(&h)
+			// While the object in the top of the queue is the next one in the
+			// order it can be passed to fn and removed from the queue, if not,
+			// we keep pushing objects into the queue.
+			for h.Len() > 0 && h[0].Priority == order {
+				callWithNode(fn, heap.Pop(&h).(PQueueNode))
 				order++
 			}
 		}
-		// Send to outCh any object remaining in the queue
+		// Pass to fn any object remaining in the queue
 		for h.Len() > 0 {
-			item := heap.Pop(&h).(PQueueNode).Data
-			if obj, ok := item.(*vt.Object); ok {
-				outCh <- obj
-			} else {
-				errCh <- item.(error)
-			}
+			callWithNode(fn, heap.Pop(&h).(PQueueNode))
 		}
 		outWg.Done()
 	}()
@@ -131,8 +153,29 @@ func (c *APIClient) RetrieveObjects(endpoint string, args []string, outCh chan *
 	// Once all object were retrieved is safe to close objCh.
 	close(objCh)
 
-	// Wait for objects to be sent to outCh
+	// Wait for objects to be passed to fn
 	outWg.Wait()
+}
 
-	return nil
+// callWithNode calls fn with the object or error contained in a PQueueNode.
+func callWithNode(fn func(int, *vt.Object, error), node PQueueNode) {
+	if obj, ok := node.Data.(*vt.Object); ok {
+		fn(node.Priority, obj, nil)
+	} else {
+		fn(node.Priority, nil, node.Data.(error))
+	}
+}
+
+// Reanalyse asks VirusTotal to analyse again the object at the given path,
+// like "files/<sha256>" or "urls/<url id>", and returns the new analysis.
+func (c *APIClient) Reanalyse(objectPath string) (*vt.Object, error) {
+	resp, err := c.Post(vt.URL("%s/analyse", objectPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	analysis := &vt.Object{}
+	if err := json.Unmarshal(resp.Data, analysis); err != nil {
+		return nil, err
+	}
+	return analysis, nil
 }
@@ -14,11 +14,14 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"github.com/fatih/color"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/fatih/color"
+
 	vt "github.com/VirusTotal/vt-go"
 	"github.com/briandowns/spinner"
 	"github.com/plusvic/go-ansi"
@@ -30,10 +33,21 @@ import (
 type Coordinator struct {
 	Threads int
 	Spinner *spinner.Spinner
+	// Journal is optional, if not nil it records the items that were
+	// completed and the ones that remained pending.
+	Journal *Journal
+	// Printer is optional, if not nil it is used for printing the objects
+	// included in the results and for printing the results in the format
+	// specified with --format.
+	Printer *Printer
+	// Gate is optional, if not nil the objects included in the results are
+	// checked against the gate's policy.
+	Gate *Gate
 
 	printingWg *sync.WaitGroup
 	doerStates []DoerState
-	resultsCh  chan string
+	resultsCh  chan *DoerResult
+	results    []map[string]interface{}
 }
 
 // DoerState represents the current state of a Doer.
@@ -42,9 +56,88 @@ type DoerState struct {
 }
 
 // Doer is the interface that must be implemented for any type to be used with
-// DoWithStringsFromReader and DoWithStringsFromChannel.
+// DoWithStringsFromReader and DoWithStringsFromChannel. The context passed to
+// Do is cancelled when the work in progress must be aborted.
 type Doer interface {
-	Do(interface{}, *DoerState) string
+	Do(context.Context, interface{}, *DoerState) *DoerResult
+}
+
+// DoerResult is the result of processing an item with a Doer.
+type DoerResult struct {
+	// Item identifies the processed item, like a file path or a hash.
+	Item string
+	// Status is a short description of the outcome, like "ok" or "not found".
+	Status string
+	// Error is the error occurred while processing the item, if any.
+	Error error
+	// Fields contains additional information about the result, like the
+	// analysis ID or the path where a file was saved.
+	Fields map[string]interface{}
+	// Object is an optional object associated to the result, like the file
+	// report obtained after waiting for an analysis to complete.
+	Object *vt.Object
+	// Text is the text shown for the result in human-friendly output. If
+	// empty, the item is shown followed by its status.
+	Text string
+}
+
+// NewDoerResult returns a new DoerResult for the given item and status.
+func NewDoerResult(item, status string) *DoerResult {
+	return &DoerResult{Item: item, Status: status}
+}
+
+// NewDoerError returns a new DoerResult for an item that failed with err.
+func NewDoerError(item string, err error) *DoerResult {
+	return &DoerResult{Item: item, Status: "error", Error: err}
+}
+
+// With adds a field to the result and returns the result itself.
+func (r *DoerResult) With(key string, value interface{}) *DoerResult {
+	if r.Fields == nil {
+		r.Fields = make(map[string]interface{})
+	}
+	r.Fields[key] = value
+	return r
+}
+
+// String returns the human-friendly representation of the result.
+func (r *DoerResult) String() string {
+	if r.Text != "" {
+		return r.Text
+	}
+	var msg string
+	if r.Error == nil {
+		msg = color.GreenString(r.Status)
+	} else if r.Status != "error" {
+		msg = color.RedString(r.Status)
+	} else {
+		msg = color.RedString(r.Error.Error())
+	}
+	return fmt.Sprintf("%s [%s]", r.Item, msg)
+}
+
+// ToMap returns the result as a map, suitable for being printed in any of
+// the formats supported by Printer.
+func (r *DoerResult) ToMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range r.Fields {
+		m[k] = v
+	}
+	m["item"] = r.Item
+	m["status"] = r.Status
+	if r.Error != nil {
+		m["error"] = r.Error.Error()
+	}
+	if r.Object != nil {
+		obj := ObjectToMap(r.Object)
+		if viper.IsSet("include") || viper.IsSet("exclude") {
+			obj = FilterMap(obj,
+				viper.GetStringSlice("include"),
+				viper.GetStringSlice("exclude"))
+		}
+		m["object"] = obj
+	}
+	return m
 }
 
 // NewCoordinator creates a new instance of Coordinator.
@@ -62,49 +155,190 @@ func (c *Coordinator) EnableSpinner() {
 // DoWithStringsFromReader calls the Do of a type implementing the Doer
 // interface with strings read from a StringReader. The doer's Do method is
 // called once for each string, and this function doesn't exit until the
-// StringReader returns an empty string.
-func (c *Coordinator) DoWithStringsFromReader(doer Doer, reader StringReader) {
-	ch := make(chan interface{})
-	go func() {
-		for s, err := reader.ReadString(); s != "" || err == nil; s, err = reader.ReadString() {
-			ch <- s
-		}
-		close(ch)
-	}()
-	c.DoWithItemsFromChannel(doer, ch)
+// StringReader returns an empty string or the work is interrupted.
+func (c *Coordinator) DoWithStringsFromReader(ctx context.Context, doer Doer, reader StringReader) {
+	p := &producer{exhaust: inMemory(reader)}
+	c.doWithItemsFromProducer(ctx, doer, p, 0, func() (interface{}, bool) {
+		s, err := reader.ReadString()
+		return s, s != "" || err == nil
+	})
 }
 
 // DoWithObjectsFromIterator calls the Do of a type implementing the Doer
 // interface with the objects returned by a vt.Iterator. Objects returned by the
-// iterator are put in a channel with a buffer size of bufferSize.
-func (c *Coordinator) DoWithObjectsFromIterator(doer Doer, it *vt.Iterator, bufferSize int) {
-	ch := make(chan interface{}, bufferSize)
-	go func() {
-		for it.Next() {
-			ch <- it.Get()
+// iterator are put in a channel with a buffer size of bufferSize. If the work
+// is interrupted and the coordinator has a journal, the iterator's cursor is
+// recorded in the journal's Cursor.
+func (c *Coordinator) DoWithObjectsFromIterator(ctx context.Context, doer Doer, it *vt.Iterator, bufferSize int) {
+	p := &producer{position: it.Cursor}
+	c.doWithItemsFromProducer(ctx, doer, p, bufferSize, func() (interface{}, bool) {
+		if !it.Next() {
+			return nil, false
 		}
-		close(ch)
-	}()
-	c.DoWithItemsFromChannel(doer, ch)
+		return it.Get(), true
+	})
 }
 
 // DoWithItemsFromChannel calls the Do method of a type implementing the Doer
 // interface with items read from a channel. This function doesn't exit until
-// the channel is closed.
-func (c *Coordinator) DoWithItemsFromChannel(doer Doer, ch <-chan interface{}) {
+// the channel is closed or the work is interrupted. When ctx starts draining
+// (see Draining) no more items are taken from the channel, but the items in
+// progress are completed. When ctx is cancelled the items in progress are
+// aborted. If the coordinator has a journal, the items buffered in the channel
+// and the aborted ones are recorded as pending, and if the channel was not
+// closed the journal is marked as truncated.
+func (c *Coordinator) DoWithItemsFromChannel(ctx context.Context, doer Doer, ch <-chan interface{}) {
+	c.doWithItems(ctx, doer, ch)
+	if c.Journal != nil && !c.pendBuffered(ch) {
+		c.Journal.Truncated = true
+	}
+}
+
+// producer reads the items processed by a Coordinator and sends them to the
+// workers. It stops reading items when the work is interrupted.
+type producer struct {
+	// exhaust indicates that the input can be read without blocking, and
+	// therefore the items that were not read when the work was interrupted
+	// can be recorded as pending.
+	exhaust bool
+	// position is optional, if not nil it returns the position in the input
+	// after the last item read.
+	position func() string
+	// cursor is the value returned by position after reading the last item.
+	cursor string
+	// mu is held by the producer except while it is reading an item, so that
+	// the coordinator can wait for the producer to stop without waiting for
+	// reads that could block indefinitely, like reads from stdin.
+	mu sync.Mutex
+	// unsent contains the items that were read but not sent to the workers.
+	unsent []interface{}
+	// done is true if all items in the input were read.
+	done bool
+}
+
+// run reads items calling next until it returns false or ctx starts draining,
+// and sends them to ch, which is closed afterwards.
+func (p *producer) run(ctx context.Context, ch chan<- interface{}, next func() (interface{}, bool)) {
+	draining := Draining(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer close(ch)
+	for {
+		select {
+		case <-draining:
+			if p.exhaust {
+				for item, ok := next(); ok; item, ok = next() {
+					p.unsent = append(p.unsent, item)
+				}
+				p.done = true
+			}
+			return
+		default:
+		}
+		p.mu.Unlock()
+		item, ok := next()
+		p.mu.Lock()
+		if !ok {
+			p.done = true
+			return
+		}
+		if p.position != nil {
+			p.cursor = p.position()
+		}
+		select {
+		case ch <- item:
+		case <-draining:
+			p.unsent = append(p.unsent, item)
+		}
+	}
+}
 
-	c.resultsCh = make(chan string, c.Threads)
+// doWithItemsFromProducer works like DoWithItemsFromChannel, but the items
+// are obtained by calling next from a producer.
+func (c *Coordinator) doWithItemsFromProducer(ctx context.Context, doer Doer, p *producer, bufferSize int, next func() (interface{}, bool)) {
+	ch := make(chan interface{}, bufferSize)
+	go p.run(ctx, ch, next)
+	c.doWithItems(ctx, doer, ch)
+	if c.Journal == nil {
+		return
+	}
+	// Wait until the producer stops or blocks reading the next item.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.pendBuffered(ch)
+	for _, item := range p.unsent {
+		c.Journal.Pend(item)
+	}
+	if !p.done {
+		if p.cursor != "" {
+			c.Journal.Cursor = p.cursor
+		} else {
+			c.Journal.Truncated = true
+		}
+	}
+}
+
+// pendBuffered records the items buffered in ch as pending in the journal,
+// without waiting for more items. Returns true if ch was closed.
+func (c *Coordinator) pendBuffered(ch <-chan interface{}) bool {
+	for {
+		select {
+		case arg, ok := <-ch:
+			if !ok {
+				return true
+			}
+			c.Journal.Pend(arg)
+		default:
+			return false
+		}
+	}
+}
+
+// doWithItems calls the doer with the items read from ch until ch is closed
+// or the work is interrupted, and prints the results.
+func (c *Coordinator) doWithItems(ctx context.Context, doer Doer, ch <-chan interface{}) {
+
+	c.resultsCh = make(chan *DoerResult, c.Threads)
+	c.results = nil
 	c.doerStates = make([]DoerState, c.Threads)
 	wg := &sync.WaitGroup{}
+	draining := Draining(ctx)
 
 	for i := 0; i < c.Threads; i++ {
 		wg.Add(1)
 		go func(i int) {
-			for arg := range ch {
-				c.resultsCh <- doer.Do(arg, &c.doerStates[i])
+			defer wg.Done()
+			for {
+				var arg interface{}
+				var ok bool
+				// Check draining first, so that no item is taken from the
+				// channel once draining has started.
+				select {
+				case <-draining:
+					return
+				default:
+				}
+				select {
+				case <-draining:
+					return
+				case arg, ok = <-ch:
+					if !ok {
+						return
+					}
+				}
+				res := doer.Do(ctx, arg, &c.doerStates[i])
 				c.doerStates[i].Progress = ""
+				if c.Journal != nil {
+					if ctx.Err() != nil {
+						c.Journal.Pend(arg)
+					} else if res.Error != nil {
+						c.Journal.Fail(arg)
+					} else {
+						c.Journal.Complete(arg)
+					}
+				}
+				c.resultsCh <- res
 			}
-			wg.Done()
 		}(i)
 	}
 
@@ -125,11 +359,59 @@ func (c *Coordinator) DoWithItemsFromChannel(doer Doer, ch <-chan interface{}) {
 	wg.Wait()
 	close(c.resultsCh)
 	c.printingWg.Wait()
+
+	if c.structured() && len(c.results) > 0 {
+		if err := c.Printer.Print(c.results); err != nil {
+			Errorf("%v", err)
+		}
+	}
+}
+
+// structured returns true if the results must be printed with the format
+// specified with --format instead of the human-friendly text. This happens
+// when the coordinator has a printer and the format was explicitly set.
+func (c *Coordinator) structured() bool {
+	return c.Printer != nil && viper.IsSet("format")
+}
+
+// printsObject returns true if the result is printed as the object it
+// contains. This happens only in human-friendly output, structured output
+// includes the object in the result under the "object" key.
+func (c *Coordinator) printsObject(res *DoerResult) bool {
+	return res.Object != nil && res.Error == nil && c.Printer != nil && !c.structured()
+}
+
+// printResult prints a result in human-friendly form, or stores it for
+// printing it later if the output is structured. In human-friendly output
+// results that contain an object are printed as the object. If tty is true the
+// line where the result is printed is cleared, as it may contain progress
+// information.
+func (c *Coordinator) printResult(res *DoerResult, tty bool) {
+	if c.Gate != nil {
+		c.Gate.Check(res.Item, res.Object, res.Error)
+	}
+	if c.structured() {
+		c.results = append(c.results, res.ToMap())
+	} else if c.printsObject(res) {
+		if err := c.Printer.PrintObject(res.Object); err != nil {
+			Errorf("%v", err)
+		}
+	} else if tty {
+		// Results can span multiple lines, each of them may contain progress
+		// information.
+		for _, line := range strings.Split(res.String(), "\n") {
+			ansi.Printf("%s", line)
+			ansi.EraseInLine(0) // Clear to the end of the line.
+			fmt.Println()
+		}
+	} else {
+		ansi.Println(res)
+	}
 }
 
 func (c *Coordinator) printResultsOnly() {
 	for res := range c.resultsCh {
-		ansi.Println(res)
+		c.printResult(res, false)
 	}
 	c.printingWg.Done()
 }
@@ -148,9 +430,12 @@ Loop:
 			if c.Spinner != nil {
 				c.Spinner.Stop()
 			}
-			ansi.Printf("%s", res)
-			ansi.EraseInLine(0) // Clear to the end of the line.
-			fmt.Println()
+			if c.printsObject(res) {
+				// The object is printed in multiple lines, clear the
+				// progress that could be in the current one.
+				ansi.EraseInLine(0)
+			}
+			c.printResult(res, true)
 		default:
 			// Print progress for pending workers
 			lines := 0
@@ -0,0 +1,161 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// cancellingDoer cancels the context when it receives the item "cancel".
+type cancellingDoer struct {
+	cancel context.CancelFunc
+}
+
+func (d *cancellingDoer) Do(ctx context.Context, item interface{}, ds *utils.DoerState) *utils.DoerResult {
+	switch item.(string) {
+	case "cancel":
+		d.cancel()
+	case "fail":
+		return utils.NewDoerError("fail", errors.New("failed"))
+	}
+	return utils.NewDoerResult(item.(string), "ok")
+}
+
+func TestCoordinatorJournal(t *testing.T) {
+	viper.Set("silent", true)
+	defer viper.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := utils.NewCoordinator(1)
+	c.Journal = utils.NewJournal("vt test")
+	c.DoWithStringsFromReader(ctx, &cancellingDoer{cancel}, utils.NewStringArrayReader(
+		[]string{"a", "b", "cancel", "c", "d"}))
+
+	assert.Equal(t, []string{"a", "b"}, c.Journal.Completed)
+	assert.Equal(t, []string{"cancel", "c", "d"}, c.Journal.Pending)
+
+	filename := filepath.Join(t.TempDir(), "test.journal")
+	assert.NoError(t, c.Journal.Save(filename))
+
+	j, err := utils.LoadJournal(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "vt test", j.Command)
+	assert.Equal(t, []string{"cancel", "c", "d"}, j.TakePending())
+	assert.Empty(t, j.Pending)
+	assert.Equal(t, []string{"a", "b"}, j.Completed)
+}
+
+func TestCoordinatorJournalFailed(t *testing.T) {
+	viper.Set("silent", true)
+	defer viper.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := utils.NewCoordinator(1)
+	c.Journal = utils.NewJournal("vt test")
+	c.DoWithStringsFromReader(ctx, &cancellingDoer{cancel}, utils.NewStringArrayReader(
+		[]string{"a", "fail", "b"}))
+
+	assert.Equal(t, []string{"a", "b"}, c.Journal.Completed)
+	assert.Equal(t, []string{"fail"}, c.Journal.Failed)
+	assert.Empty(t, c.Journal.Pending)
+	assert.False(t, c.Journal.Truncated)
+	assert.Equal(t, []string{"fail"}, c.Journal.TakePending())
+	assert.Empty(t, c.Journal.Failed)
+}
+
+func TestCoordinatorJournalTruncated(t *testing.T) {
+	viper.Set("silent", true)
+	defer viper.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The writer is never closed, so reading after "cancel" blocks.
+	r, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("a\ncancel\n"))
+
+	c := utils.NewCoordinator(1)
+	c.Journal = utils.NewJournal("vt test")
+	c.DoWithStringsFromReader(ctx, &cancellingDoer{cancel}, utils.NewStringIOReader(r))
+
+	assert.Equal(t, []string{"a"}, c.Journal.Completed)
+	assert.Equal(t, []string{"cancel"}, c.Journal.Pending)
+	assert.True(t, c.Journal.Truncated)
+}
+
+// batch is an item that groups multiple items in the journal.
+type batch []string
+
+func (b batch) JournalItems() []string {
+	return b
+}
+
+func TestJournalItems(t *testing.T) {
+	j := utils.NewJournal("vt test")
+	j.Complete(batch{"a", "b"})
+	j.Fail("c")
+	j.Pend(batch{"d", "e"})
+	assert.Equal(t, []string{"a", "b"}, j.Completed)
+	assert.Equal(t, []string{"c", "d", "e"}, j.TakePending())
+}
+
+func TestDoerResult(t *testing.T) {
+	res := utils.NewDoerResult("foo.exe", "ok").
+		With("analysis_id", "f-1234").
+		With("bytes", 10)
+	assert.Equal(t, map[string]interface{}{
+		"item":        "foo.exe",
+		"status":      "ok",
+		"analysis_id": "f-1234",
+		"bytes":       10,
+	}, res.ToMap())
+	assert.Contains(t, res.String(), "foo.exe [")
+	assert.Contains(t, res.String(), "ok")
+
+	res.Text = "foo.exe f-1234"
+	assert.Equal(t, "foo.exe f-1234", res.String())
+
+	res = utils.NewDoerError("bar.exe", errors.New("permission denied"))
+	assert.Equal(t, map[string]interface{}{
+		"item":   "bar.exe",
+		"status": "error",
+		"error":  "permission denied",
+	}, res.ToMap())
+	assert.Contains(t, res.String(), "permission denied")
+
+	res = utils.NewDoerResult("baz.exe", "ok")
+	res.Object = vt.NewObjectWithID("analysis", "f-5678")
+	assert.Equal(t, map[string]interface{}{
+		"item":   "baz.exe",
+		"status": "ok",
+		"object": map[string]interface{}{
+			"_id":   "f-5678",
+			"_type": "analysis",
+		},
+	}, res.ToMap())
+}
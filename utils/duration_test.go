@@ -0,0 +1,40 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"":      0,
+		"90m":   90 * time.Minute,
+		"7d":    7 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1d12h": 36 * time.Hour,
+	} {
+		d, err := utils.ParseDuration(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+	for _, s := range []string{"7", "d", "1dd", "week"} {
+		_, err := utils.ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	vt "github.com/VirusTotal/vt-go"
+)
+
+// DefaultVerdict contains the names of the items included by default in the
+// verdict blocks added by Enricher.
+var DefaultVerdict = []string{"malicious", "suspicious", "reputation", "labels"}
+
+// EnrichField is a field of an event that must be enriched, together with the
+// type of the values it contains.
+type EnrichField struct {
+	// Name is the field's path, like "file.sha256".
+	Name string
+	// Type is the type of the values in the field: "file", "url", "domain",
+	// "ip" or "auto". With "auto" the type of each value is detected with
+	// DetectIOCType.
+	Type string
+	path []string
+}
+
+// ParseEnrichField parses a field specification with the form <path>:<type>,
+// like "dest_ip:ip" or "file.sha256:file". If the type is omitted it is
+// "auto".
+func ParseEnrichField(s string) (EnrichField, error) {
+	name, t := s, "auto"
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		name, t = s[:i], strings.ToLower(s[i+1:])
+	}
+	switch t {
+	case "file", "url", "domain", "ip", "auto":
+	default:
+		return EnrichField{}, fmt.Errorf(
+			"invalid type %q in field %q, use file, url, domain, ip or auto", t, s)
+	}
+	if name == "" {
+		return EnrichField{}, fmt.Errorf("invalid field %q", s)
+	}
+	return EnrichField{Name: name, Type: t, path: strings.Split(name, ".")}, nil
+}
+
+// objectPath returns the API path for the object corresponding to a value of
+// the field. The second value returned is false if the value doesn't have the
+// field's type.
+func (f EnrichField) objectPath(value string) (string, bool) {
+	t, ok := DetectIOCType(value)
+	switch f.Type {
+	case "file":
+		ok = ok && (t == IOCMD5 || t == IOCSHA1 || t == IOCSHA256)
+	case "ip":
+		ok = ok && (t == IOCIPv4 || t == IOCIPv6)
+	case "domain":
+		ok = ok && t == IOCDomain
+	case "url":
+		t, ok = IOCURL, true
+	}
+	if !ok {
+		return "", false
+	}
+	return ObjectPath(t, value)
+}
+
+// guiPaths maps object types to the path used for them in VirusTotal's web
+// interface.
+var guiPaths = map[string]string{
+	"file":       "file",
+	"url":        "url",
+	"domain":     "domain",
+	"ip_address": "ip-address",
+}
+
+// Verdict returns a summary of the given object with the specified items.
+// Valid items are "malicious", "suspicious", "harmless" and "undetected",
+// which are taken from last_analysis_stats, "reputation", "labels", which
+// combines the object's tags, suggested threat label and categories, and
+// "link", the object's URL in VirusTotal's web interface. Any other item is
+// interpreted as an attribute path, like "last_analysis_date". The result
+// always includes the object's ID.
+func Verdict(obj *vt.Object, items []string) map[string]interface{} {
+	v := map[string]interface{}{"found": true, "id": obj.ID()}
+	for _, item := range items {
+		switch item {
+		case "malicious", "suspicious", "harmless", "undetected":
+			n, _ := obj.GetInt64("last_analysis_stats." + item)
+			v[item] = n
+		case "reputation":
+			n, _ := obj.GetInt64("reputation")
+			v[item] = n
+		case "labels":
+			v[item] = objectLabels(obj)
+		case "link":
+			if p, ok := guiPaths[obj.Type()]; ok {
+				v[item] = fmt.Sprintf("https://www.virustotal.com/gui/%s/%s", p, obj.ID())
+			}
+		default:
+			if value, err := obj.Get(item); err == nil && value != nil {
+				v[item] = value
+			}
+		}
+	}
+	return v
+}
+
+// objectLabels returns the labels associated to an object, which are its tags,
+// its suggested threat label, if any, and the categories assigned to it by
+// different engines.
+func objectLabels(obj *vt.Object) []string {
+	seen := make(map[string]bool)
+	labels := make([]string, 0)
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			labels = append(labels, l)
+		}
+	}
+	if label, err := obj.GetString("popular_threat_classification.suggested_threat_label"); err == nil {
+		add(label)
+	}
+	if tags, err := obj.GetStringSlice("tags"); err == nil {
+		for _, t := range tags {
+			add(t)
+		}
+	}
+	if categories, err := obj.Get("categories"); err == nil {
+		if m, ok := categories.(map[string]interface{}); ok {
+			engines := make([]string, 0, len(m))
+			for engine := range m {
+				engines = append(engines, engine)
+			}
+			sort.Strings(engines)
+			for _, engine := range engines {
+				if c, ok := m[engine].(string); ok {
+					add(c)
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// Enricher adds verdicts about the indicators found in events, like the
+// ones produced by security products, to the events themselves. Events are
+// decoded JSON objects. For each field to enrich a verdict block is added to
+// the event under Key, like in {"vt": {"file.sha256": {...}}}. If the field
+// contains an array, the block is an array with a verdict for each item.
+// Verdicts are cached, so each indicator is looked up only once.
+type Enricher struct {
+	Fields []EnrichField
+	// Verdict contains the items included in each verdict, see Verdict.
+	Verdict []string
+	// Key is the name of the field where verdict blocks are added.
+	Key string
+	// CacheSize is the maximum number of verdicts kept in the cache. When
+	// the limit is reached the cache is emptied.
+	CacheSize int
+
+	client *APIClient
+	cache  map[string]map[string]interface{}
+}
+
+// NewEnricher creates a new Enricher that uses the given client for looking
+// up indicators.
+func NewEnricher(client *APIClient, fields []EnrichField) *Enricher {
+	return &Enricher{
+		Fields:    fields,
+		Verdict:   DefaultVerdict,
+		Key:       "vt",
+		CacheSize: 100000,
+		client:    client,
+		cache:     make(map[string]map[string]interface{}),
+	}
+}
+
+// Enrich adds verdict blocks to the given events. Events that are not JSON
+// objects are left untouched. Indicators that are not in the cache are looked
+// up in parallel, with the number of threads specified with --threads.
+func (e *Enricher) Enrich(ctx context.Context, events []interface{}) {
+	if len(e.cache) >= e.CacheSize {
+		e.cache = make(map[string]map[string]interface{})
+	}
+
+	pending := make([]string, 0)
+	queued := make(map[string]bool)
+	for _, event := range events {
+		for _, f := range e.Fields {
+			for _, value := range jsonPathValues(event, f.path) {
+				path, ok := f.objectPath(value)
+				if _, cached := e.cache[path]; ok && !cached && !queued[path] {
+					queued[path] = true
+					pending = append(pending, path)
+				}
+			}
+		}
+	}
+
+	// Errors other than "not found", like the ones caused by an interruption,
+	// are reported in the verdicts but not cached.
+	errs := make(map[string]map[string]interface{})
+	if len(pending) > 0 {
+		e.client.RetrieveObjectsFunc(ctx, "%s", pending,
+			func(i int, obj *vt.Object, err error) {
+				if err == nil {
+					e.cache[pending[i]] = Verdict(obj, e.Verdict)
+				} else if apiErr, ok := err.(vt.Error); ok && apiErr.Code == "NotFoundError" {
+					e.cache[pending[i]] = map[string]interface{}{"found": false}
+				} else {
+					errs[pending[i]] = map[string]interface{}{"found": false, "error": err.Error()}
+				}
+			})
+	}
+
+	for _, event := range events {
+		m, ok := event.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		blocks := make(map[string]interface{})
+		for _, f := range e.Fields {
+			values := jsonPathValues(m, f.path)
+			verdicts := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				var v map[string]interface{}
+				if path, ok := f.objectPath(value); !ok {
+					v = map[string]interface{}{"found": false, "error": "unsupported value"}
+				} else if err, failed := errs[path]; failed {
+					v = copyVerdict(err)
+				} else {
+					v = copyVerdict(e.cache[path])
+				}
+				v["value"] = value
+				verdicts = append(verdicts, v)
+			}
+			if len(verdicts) == 0 {
+				continue
+			}
+			if _, isArray := jsonPathValue(m, f.path).([]interface{}); isArray {
+				blocks[f.Name] = verdicts
+			} else {
+				blocks[f.Name] = verdicts[0]
+			}
+		}
+		if len(blocks) > 0 {
+			m[e.Key] = blocks
+		}
+	}
+}
+
+// copyVerdict returns a copy of v, so that it can be modified without
+// modifying the cached verdict.
+func copyVerdict(v map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(v)+1)
+	for k, val := range v {
+		c[k] = val
+	}
+	return c
+}
+
+// EnrichJSONLines enriches multiple lines of JSON Lines data, returning the
+// lines with the verdicts added. Lines that are not valid JSON are returned as
+// they are.
+func (e *Enricher) EnrichJSONLines(ctx context.Context, lines [][]byte) [][]byte {
+	events := make([]interface{}, len(lines))
+	for i, line := range lines {
+		var event interface{}
+		dec := json.NewDecoder(strings.NewReader(string(line)))
+		dec.UseNumber()
+		if err := dec.Decode(&event); err == nil {
+			events[i] = event
+		}
+	}
+	e.Enrich(ctx, events)
+	out := make([][]byte, len(lines))
+	for i, event := range events {
+		if _, ok := event.(map[string]interface{}); !ok {
+			out[i] = lines[i]
+			continue
+		}
+		if b, err := json.Marshal(event); err == nil {
+			out[i] = b
+		} else {
+			out[i] = lines[i]
+		}
+	}
+	return out
+}
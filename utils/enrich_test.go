@@ -0,0 +1,160 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPIClient returns an API client that sends its requests to a test
+// server that answers with handler.
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc) *utils.APIClient {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	vt.SetHost(ts.URL)
+	t.Cleanup(func() { vt.SetHost("https://www.virustotal.com") })
+	viper.Set("apikey", "test-api-key")
+	viper.Set("threads", 2)
+	t.Cleanup(viper.Reset)
+	client, err := utils.NewAPIClient("test")
+	assert.NoError(t, err)
+	return client
+}
+
+func TestParseEnrichField(t *testing.T) {
+	f, err := utils.ParseEnrichField("file.sha256:file")
+	assert.NoError(t, err)
+	assert.Equal(t, "file.sha256", f.Name)
+	assert.Equal(t, "file", f.Type)
+
+	f, err = utils.ParseEnrichField("indicator")
+	assert.NoError(t, err)
+	assert.Equal(t, "auto", f.Type)
+
+	_, err = utils.ParseEnrichField("dest_ip:ipv4")
+	assert.Error(t, err)
+	_, err = utils.ParseEnrichField(":ip")
+	assert.Error(t, err)
+}
+
+func TestEnricher(t *testing.T) {
+	var requests int32
+	client := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		parts := strings.Split(r.URL.Path, "/")
+		collection, id := parts[3], parts[4]
+		w.Header().Set("Content-Type", "application/json")
+		if id == "1.1.1.1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "NotFoundError", "message": "not found"}}`))
+			return
+		}
+		objType := map[string]string{"files": "file", "ip_addresses": "ip_address"}[collection]
+		fmt.Fprintf(w, `{"data": {"type": %q, "id": %q, "attributes": {
+			"last_analysis_stats": {"malicious": 4, "suspicious": 1},
+			"reputation": -10,
+			"tags": ["peexe"],
+			"popular_threat_classification": {"suggested_threat_label": "trojan.emotet"}}}}`,
+			objType, id)
+	})
+
+	fields := make([]utils.EnrichField, 0)
+	for _, spec := range []string{"dest_ip:ip", "file.sha256:file", "ips:ip"} {
+		f, err := utils.ParseEnrichField(spec)
+		assert.NoError(t, err)
+		fields = append(fields, f)
+	}
+	e := utils.NewEnricher(client, fields)
+
+	lines := [][]byte{
+		[]byte(`{"dest_ip": "8.8.8.8", "file": {"sha256": "44d88612fea8a8f36de82e1278abb02f"}}`),
+		[]byte(`not json`),
+		[]byte(`{"dest_ip": "8.8.8.8", "ips": ["1.1.1.1", "8.8.8.8"], "n": 12345678901234}`),
+		[]byte(`{"other": "field"}`),
+	}
+	out := e.EnrichJSONLines(context.Background(), lines)
+	assert.Len(t, out, 4)
+	assert.Equal(t, "not json", string(out[1]))
+	assert.Equal(t, `{"other":"field"}`, string(out[3]))
+
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out[0], &event))
+	verdicts := event["vt"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"found":      true,
+		"id":         "8.8.8.8",
+		"value":      "8.8.8.8",
+		"malicious":  float64(4),
+		"suspicious": float64(1),
+		"reputation": float64(-10),
+		"labels":     []interface{}{"trojan.emotet", "peexe"},
+	}, verdicts["dest_ip"])
+	assert.Contains(t, verdicts, "file.sha256")
+
+	event = nil
+	assert.NoError(t, json.Unmarshal(out[2], &event))
+	// Large numbers in the original event are preserved.
+	assert.Contains(t, string(out[2]), `"n":12345678901234`)
+	ips := event["vt"].(map[string]interface{})["ips"].([]interface{})
+	assert.Len(t, ips, 2)
+	assert.Equal(t, map[string]interface{}{"found": false, "value": "1.1.1.1"}, ips[0])
+	assert.Equal(t, true, ips[1].(map[string]interface{})["found"])
+
+	// 8.8.8.8, 1.1.1.1 and the file were looked up only once.
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// Verdicts are cached across calls.
+	e.EnrichJSONLines(context.Background(), lines[:1])
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestEnricherUnsafeValues(t *testing.T) {
+	var paths []string
+	client := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"code": "NotFoundError", "message": "not found"}}`))
+	})
+	viper.Set("threads", 1)
+
+	fields := make([]utils.EnrichField, 0)
+	for _, spec := range []string{"domain:domain", "url:url"} {
+		f, err := utils.ParseEnrichField(spec)
+		assert.NoError(t, err)
+		fields = append(fields, f)
+	}
+	e := utils.NewEnricher(client, fields)
+
+	out := e.EnrichJSONLines(context.Background(), [][]byte{
+		[]byte(`{"domain": "a/../../users/me", "url": "a%zz"}`),
+	})
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out[0], &event))
+	verdicts := event["vt"].(map[string]interface{})
+	assert.Equal(t, "unsupported value", verdicts["domain"].(map[string]interface{})["error"])
+	assert.Equal(t, []string{"/api/v3/urls/YSV6eg"}, paths)
+}
@@ -15,27 +15,8 @@ package utils
 
 import (
 	"os"
-	"path"
 )
 
-// FileDirReader returns all files inside a given directory
-// as a StringArrayReader
-func NewFileDirReader(fileDir string) (*StringArrayReader, error) {
-	files, err := os.ReadDir(fileDir)
-	if err != nil {
-		return nil, err
-	}
-	fileNames := []string{}
-	for _, f := range files {
-		// Skip subdirectories
-		if f.IsDir() {
-			continue
-		}
-		fileNames = append(fileNames, path.Join(fileDir, f.Name()))
-	}
-	return &StringArrayReader{strings: fileNames}, nil
-}
-
 // IsDir function returns whether a file is a directory or not
 func IsDir(f string) bool {
 	fileInfo, err := os.Stat(f)
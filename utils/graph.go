@@ -0,0 +1,265 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Verdicts assigned to the nodes in a Graph.
+const (
+	VerdictMalicious  = "malicious"
+	VerdictSuspicious = "suspicious"
+	VerdictHarmless   = "harmless"
+	VerdictUnknown    = "unknown"
+)
+
+// verdictColors maps verdicts to the colors used for them in exported
+// graphs.
+var verdictColors = map[string]string{
+	VerdictMalicious:  "#e53935",
+	VerdictSuspicious: "#fb8c00",
+	VerdictHarmless:   "#43a047",
+	VerdictUnknown:    "#bdbdbd",
+}
+
+// dotShapes maps object types to the shapes used for them in DOT graphs.
+var dotShapes = map[string]string{
+	"file":       "box",
+	"url":        "note",
+	"domain":     "ellipse",
+	"ip_address": "diamond",
+}
+
+// GraphNode is a node in a Graph, representing a VirusTotal object like a
+// file, URL, domain or IP address.
+type GraphNode struct {
+	// Type is the object's type, like "file" or "ip_address".
+	Type string
+	// ID is the object's ID.
+	ID string
+	// Label is a human-friendly name for the node, like a file name or a
+	// URL. If empty the ID is used.
+	Label string
+	// Depth is the distance to the node where the graph started.
+	Depth int
+	// Verdict is one of VerdictMalicious, VerdictSuspicious, VerdictHarmless
+	// or VerdictUnknown.
+	Verdict string
+	// Stats contains the object's last_analysis_stats, if known.
+	Stats map[string]int64
+}
+
+// Key returns the string that identifies the node in the graph, which
+// includes its type, as objects of different types can have the same ID.
+func (n *GraphNode) Key() string {
+	return n.Type + "/" + n.ID
+}
+
+func (n *GraphNode) label() string {
+	if n.Label != "" {
+		return n.Label
+	}
+	return n.ID
+}
+
+func (n *GraphNode) color() string {
+	if c, ok := verdictColors[n.Verdict]; ok {
+		return c
+	}
+	return verdictColors[VerdictUnknown]
+}
+
+// GraphEdge is a directed edge in a Graph, from an object to an object
+// related to it.
+type GraphEdge struct {
+	From         *GraphNode
+	To           *GraphNode
+	Relationship string
+}
+
+// Graph is a directed graph of VirusTotal objects and the relationships
+// between them. Nodes and edges are kept in the order in which they were
+// added, and they are deduplicated.
+type Graph struct {
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+
+	nodes map[string]*GraphNode
+	edges map[string]bool
+}
+
+// NewGraph returns a new empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		nodes: make(map[string]*GraphNode),
+		edges: make(map[string]bool),
+	}
+}
+
+// AddNode adds a node to the graph, unless the graph already has a node with
+// the same type and ID. It returns the node in the graph, and true if it was
+// added.
+func (g *Graph) AddNode(n *GraphNode) (*GraphNode, bool) {
+	if existing, ok := g.nodes[n.Key()]; ok {
+		return existing, false
+	}
+	g.nodes[n.Key()] = n
+	g.Nodes = append(g.Nodes, n)
+	return n, true
+}
+
+// Node returns the node with the given type and ID, or nil if the graph
+// doesn't have it.
+func (g *Graph) Node(objectType, id string) *GraphNode {
+	return g.nodes[objectType+"/"+id]
+}
+
+// AddEdge adds an edge between two nodes in the graph, unless the graph
+// already has an edge between them for the same relationship.
+func (g *Graph) AddEdge(from, to *GraphNode, relationship string) {
+	key := from.Key() + "\x00" + to.Key() + "\x00" + relationship
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	g.Edges = append(g.Edges, &GraphEdge{From: from, To: to, Relationship: relationship})
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Nodes are filled with a
+// color that depends on their verdict, and their shape depends on their type.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph vt {\n")
+	b.WriteString("  node [style=filled, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, n := range g.Nodes {
+		shape, ok := dotShapes[n.Type]
+		if !ok {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, tooltip=%s, shape=%s, fillcolor=%s];\n",
+			dotQuote(n.Key()), dotQuote(n.label()), dotQuote(n.ID), shape, dotQuote(n.color()))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n",
+			dotQuote(e.From.Key()), dotQuote(e.To.Key()), dotQuote(e.Relationship))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// graphMLEscape returns s escaped for being included in XML.
+func graphMLEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// WriteGraphML writes the graph in GraphML format. Nodes have their type,
+// label, verdict, color and analysis stats as data, and edges have the
+// relationship.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, k := range []struct{ id, domain, typ string }{
+		{"type", "node", "string"},
+		{"label", "node", "string"},
+		{"verdict", "node", "string"},
+		{"color", "node", "string"},
+		{"malicious", "node", "long"},
+		{"suspicious", "node", "long"},
+		{"depth", "node", "int"},
+		{"relationship", "edge", "string"},
+	} {
+		fmt.Fprintf(&b, `  <key id="%s" for="%s" attr.name="%s" attr.type="%s"/>`+"\n",
+			k.id, k.domain, k.id, k.typ)
+	}
+	b.WriteString(`  <graph id="vt" edgedefault="directed">` + "\n")
+	data := func(key string, value interface{}) {
+		fmt.Fprintf(&b, `      <data key="%s">%s</data>`+"\n",
+			key, graphMLEscape(fmt.Sprint(value)))
+	}
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, `    <node id="%s">`+"\n", graphMLEscape(n.Key()))
+		data("type", n.Type)
+		data("label", n.label())
+		data("verdict", n.Verdict)
+		data("color", n.color())
+		if n.Stats != nil {
+			data("malicious", n.Stats["malicious"])
+			data("suspicious", n.Stats["suspicious"])
+		}
+		data("depth", n.Depth)
+		b.WriteString("    </node>\n")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(&b, `    <edge id="e%d" source="%s" target="%s">`+"\n",
+			i, graphMLEscape(e.From.Key()), graphMLEscape(e.To.Key()))
+		data("relationship", e.Relationship)
+		b.WriteString("    </edge>\n")
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCytoscape writes the graph in the JSON format used by Cytoscape and
+// Cytoscape.js, with the same data for nodes and edges as WriteGraphML.
+func (g *Graph) WriteCytoscape(w io.Writer) error {
+	type element struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	nodes := make([]element, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		data := map[string]interface{}{
+			"id":      n.Key(),
+			"type":    n.Type,
+			"label":   n.label(),
+			"verdict": n.Verdict,
+			"color":   n.color(),
+			"depth":   n.Depth,
+		}
+		if n.Stats != nil {
+			data["malicious"] = n.Stats["malicious"]
+			data["suspicious"] = n.Stats["suspicious"]
+		}
+		nodes = append(nodes, element{data})
+	}
+	edges := make([]element, 0, len(g.Edges))
+	for i, e := range g.Edges {
+		edges = append(edges, element{map[string]interface{}{
+			"id":           fmt.Sprintf("e%d", i),
+			"source":       e.From.Key(),
+			"target":       e.To.Key(),
+			"relationship": e.Relationship,
+		}})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"elements": map[string]interface{}{"nodes": nodes, "edges": edges},
+	})
+}
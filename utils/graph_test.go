@@ -0,0 +1,133 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeGraph() *utils.Graph {
+	g := utils.NewGraph()
+	file, _ := g.AddNode(&utils.GraphNode{
+		Type: "file", ID: "abc", Label: `evil "dropper".exe`,
+		Verdict: utils.VerdictMalicious,
+		Stats:   map[string]int64{"malicious": 40, "suspicious": 2}})
+	domain, _ := g.AddNode(&utils.GraphNode{
+		Type: "domain", ID: "evil.example.com", Depth: 1, Verdict: utils.VerdictUnknown})
+	ip, _ := g.AddNode(&utils.GraphNode{
+		Type: "ip_address", ID: "192.0.2.1", Depth: 2, Verdict: utils.VerdictHarmless,
+		Stats: map[string]int64{}})
+	g.AddEdge(file, domain, "contacted_domains")
+	g.AddEdge(domain, ip, "resolutions")
+	return g
+}
+
+func TestGraphDedupe(t *testing.T) {
+	g := makeGraph()
+	n, added := g.AddNode(&utils.GraphNode{Type: "domain", ID: "evil.example.com", Depth: 3})
+	assert.False(t, added)
+	assert.Equal(t, 1, n.Depth)
+	assert.Same(t, n, g.Node("domain", "evil.example.com"))
+	assert.Nil(t, g.Node("file", "evil.example.com"))
+
+	// Objects of different types can have the same ID.
+	_, added = g.AddNode(&utils.GraphNode{Type: "url", ID: "abc"})
+	assert.True(t, added)
+	assert.Len(t, g.Nodes, 4)
+
+	g.AddEdge(g.Nodes[0], n, "contacted_domains")
+	g.AddEdge(g.Nodes[0], n, "embedded_domains")
+	assert.Len(t, g.Edges, 3)
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, makeGraph().WriteDOT(buf))
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph vt {\n"))
+	assert.Contains(t, dot,
+		`"file/abc" [label="evil \"dropper\".exe", tooltip="abc", shape=box, fillcolor="#e53935"];`)
+	assert.Contains(t, dot,
+		`"domain/evil.example.com" [label="evil.example.com", tooltip="evil.example.com", shape=ellipse, fillcolor="#bdbdbd"];`)
+	assert.Contains(t, dot,
+		`"domain/evil.example.com" -> "ip_address/192.0.2.1" [label="resolutions"];`)
+}
+
+func TestGraphWriteGraphML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, makeGraph().WriteGraphML(buf))
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(t, doc.Graph.Nodes, 3)
+	assert.Equal(t, "file/abc", doc.Graph.Nodes[0].ID)
+	data := make(map[string]string)
+	for _, d := range doc.Graph.Nodes[0].Data {
+		data[d.Key] = d.Value
+	}
+	assert.Equal(t, map[string]string{
+		"type": "file", "label": `evil "dropper".exe`, "verdict": "malicious",
+		"color": "#e53935", "malicious": "40", "suspicious": "2", "depth": "0",
+	}, data)
+	assert.Len(t, doc.Graph.Edges, 2)
+	assert.Equal(t, "domain/evil.example.com", doc.Graph.Edges[1].Source)
+	assert.Equal(t, "ip_address/192.0.2.1", doc.Graph.Edges[1].Target)
+}
+
+func TestGraphWriteCytoscape(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, makeGraph().WriteCytoscape(buf))
+
+	var doc struct {
+		Elements struct {
+			Nodes []struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"nodes"`
+			Edges []struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"edges"`
+		} `json:"elements"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(t, doc.Elements.Nodes, 3)
+	assert.Equal(t, "domain/evil.example.com", doc.Elements.Nodes[1].Data["id"])
+	assert.Equal(t, "#bdbdbd", doc.Elements.Nodes[1].Data["color"])
+	assert.NotContains(t, doc.Elements.Nodes[1].Data, "malicious")
+	assert.Equal(t, float64(0), doc.Elements.Nodes[2].Data["malicious"])
+	assert.Equal(t, map[string]interface{}{
+		"id": "e0", "source": "file/abc", "target": "domain/evil.example.com",
+		"relationship": "contacted_domains",
+	}, doc.Elements.Edges[0].Data)
+}
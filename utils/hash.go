@@ -0,0 +1,74 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileHashes contains the hashes of a file's content.
+type FileHashes struct {
+	MD5    string
+	SHA1   string
+	SHA256 string
+	Size   int64
+}
+
+// HashReader reads all the data in r and returns its hashes.
+func HashReader(r io.Reader) (*FileHashes, error) {
+	h256, h1, h5 := sha256.New(), sha1.New(), md5.New()
+	n, err := io.Copy(io.MultiWriter(h256, h1, h5), r)
+	if err != nil {
+		return nil, err
+	}
+	return &FileHashes{
+		MD5:    hex.EncodeToString(h5.Sum(nil)),
+		SHA1:   hex.EncodeToString(h1.Sum(nil)),
+		SHA256: hex.EncodeToString(h256.Sum(nil)),
+		Size:   n,
+	}, nil
+}
+
+// HashFile returns the hashes of the file at the given path.
+func HashFile(path string) (*FileHashes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return HashReader(f)
+}
+
+// Matches returns true if hash, which can be a MD5, SHA-1 or SHA-256 in
+// hexadecimal, is the corresponding hash in h.
+func (h *FileHashes) Matches(hash string) bool {
+	var expected string
+	switch len(hash) {
+	case 32:
+		expected = h.MD5
+	case 40:
+		expected = h.SHA1
+	case 64:
+		expected = h.SHA256
+	default:
+		return false
+	}
+	return strings.EqualFold(hash, expected)
+}
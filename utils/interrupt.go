@@ -0,0 +1,101 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+type drainKey struct{}
+
+// WithInterrupt returns a context that handles interrupt signals (SIGINT and
+// SIGTERM) in stages. The first signal puts the context in draining mode: no
+// new work should be started, but the work in progress can finish. Use
+// Draining for knowing when this happens. The second signal cancels the
+// context, aborting the work in progress. The third signal terminates the
+// program immediately. The returned function must be called for releasing
+// the resources associated to the context.
+func WithInterrupt(parent context.Context) (context.Context, func()) {
+	drainCtx, drain := context.WithCancel(parent)
+	ctx, abort := context.WithCancel(context.WithValue(parent, drainKey{}, drainCtx))
+
+	sigCh := make(chan os.Signal, 3)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		n := 0
+		for {
+			select {
+			case <-sigCh:
+				n++
+				switch n {
+				case 1:
+					Warnf("\nInterrupted, waiting for pending work to finish. Press Ctrl-C again to abort.")
+					drain()
+				case 2:
+					Warnf("\nAborting...")
+					abort()
+				default:
+					os.Exit(130)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigCh)
+		close(done)
+		drain()
+		abort()
+	}
+}
+
+// Draining returns a channel that is closed when no new work should be
+// started in the given context. For contexts created with WithInterrupt this
+// happens after the first interrupt signal, for any other context it happens
+// when the context is done.
+func Draining(ctx context.Context) <-chan struct{} {
+	if drainCtx, ok := ctx.Value(drainKey{}).(context.Context); ok {
+		// The draining context is not derived from ctx, so make sure that
+		// the channel is closed also when ctx is cancelled.
+		ch := make(chan struct{})
+		go func() {
+			select {
+			case <-drainCtx.Done():
+			case <-ctx.Done():
+			}
+			close(ch)
+		}()
+		return ch
+	}
+	return ctx.Done()
+}
+
+// Interrupted returns true if the work in the given context was interrupted,
+// either because it is draining or because it was cancelled.
+func Interrupted(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	if drainCtx, ok := ctx.Value(drainKey{}).(context.Context); ok {
+		return drainCtx.Err() != nil
+	}
+	return false
+}
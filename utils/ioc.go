@@ -0,0 +1,444 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// IOCType is the type of an indicator of compromise.
+type IOCType string
+
+// Types of indicators of compromise recognized by ExtractIOCs.
+const (
+	IOCMD5    IOCType = "md5"
+	IOCSHA1   IOCType = "sha1"
+	IOCSHA256 IOCType = "sha256"
+	IOCURL    IOCType = "url"
+	IOCDomain IOCType = "domain"
+	IOCIPv4   IOCType = "ipv4"
+	IOCIPv6   IOCType = "ipv6"
+	IOCCVE    IOCType = "cve"
+	// IOCAnalysis is the type of analysis identifiers, like the ones returned
+	// by "vt scan". It is never returned by ExtractIOCs, only by DetectIOCType.
+	IOCAnalysis IOCType = "analysis"
+)
+
+// IOCTypes contains all the IOC types, in the order in which they are listed
+// in help messages.
+var IOCTypes = []IOCType{
+	IOCMD5, IOCSHA1, IOCSHA256, IOCURL, IOCDomain, IOCIPv4, IOCIPv6, IOCCVE}
+
+// iocTypeAliases are names that can be used in ParseIOCTypes for referring to
+// multiple types at once.
+var iocTypeAliases = map[string][]IOCType{
+	"hash": {IOCMD5, IOCSHA1, IOCSHA256},
+	"ip":   {IOCIPv4, IOCIPv6},
+}
+
+// ParseIOCTypes parses a list of IOC type names. Besides the names of the
+// types, "hash" can be used for referring to all hash types, and "ip" for
+// referring to both IPv4 and IPv6 addresses.
+func ParseIOCTypes(names []string) ([]IOCType, error) {
+	types := make([]IOCType, 0)
+Names:
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := iocTypeAliases[name]; ok {
+			types = append(types, alias...)
+			continue
+		}
+		for _, t := range IOCTypes {
+			if string(t) == name {
+				types = append(types, t)
+				continue Names
+			}
+		}
+		return nil, fmt.Errorf("unknown IOC type %q", name)
+	}
+	return types, nil
+}
+
+// IOC is an indicator of compromise found in a text.
+type IOC struct {
+	Type  IOCType
+	Value string
+}
+
+// refangReplacer replaces the most common ways of defanging indicators with
+// the characters they stand for.
+var refangReplacer = strings.NewReplacer(
+	"[.]", ".", "(.)", ".", "{.}", ".", "[dot]", ".", "(dot)", ".", "{dot}", ".",
+	"[DOT]", ".", "(DOT)", ".", "{DOT}", ".", " [.] ", ".", "\\.", ".",
+	"[:]", ":", "[://]", "://", "[/]", "/", "[at]", "@", "(at)", "@",
+)
+
+// defangedSchemeRe matches defanged URL schemes like hxxp, hXXps, h__p or fxp.
+var defangedSchemeRe = regexp.MustCompile(`(?i)\b(h(?:xx|\*\*|__|tt)p(s?)|fxp)(\[?:\]?//)`)
+
+// Refang reverts the modifications usually made to indicators of compromise
+// for making them non-clickable, like replacing dots with [.] or (dot) and
+// "http" with "hxxp".
+func Refang(text string) string {
+	text = refangReplacer.Replace(text)
+	return defangedSchemeRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := defangedSchemeRe.FindStringSubmatch(s)
+		if strings.EqualFold(m[1], "fxp") {
+			return "ftp://"
+		}
+		return "http" + strings.ToLower(m[2]) + "://"
+	})
+}
+
+var (
+	iocURLRe    = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>"'` + "`" + `]+`)
+	iocHashRe   = regexp.MustCompile(`\b[[:xdigit:]]{32,64}\b`)
+	iocCVERe    = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	iocIPv4Re   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	iocIPv6Re   = regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}(?:[0-9]{1,3}(?:\.[0-9]{1,3}){3})?`)
+	iocDomainRe = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?:xn--[a-z0-9-]{1,59}|[a-z]{2,63})\b`)
+)
+
+// nonTLDs contains file extensions that are commonly found in texts and are
+// also valid top-level domains, or could be confused with them. Domains ending
+// with any of them are ignored.
+var nonTLDs = map[string]bool{
+	"md": true, "py": true,
+	"exe": true, "dll": true, "sys": true, "bat": true, "cmd": true,
+	"ps1": true, "vbs": true, "js": true, "jar": true, "pdf": true,
+	"doc": true, "docx": true, "docm": true, "xls": true, "xlsx": true,
+	"xlsm": true, "ppt": true, "pptx": true, "rtf": true, "txt": true,
+	"log": true, "tmp": true, "dat": true, "bin": true, "png": true,
+	"jpg": true, "jpeg": true, "gif": true, "bmp": true, "htm": true,
+	"html": true, "php": true, "asp": true, "aspx": true, "json": true,
+	"xml": true, "yaml": true, "yml": true, "csv": true, "ini": true,
+	"cfg": true, "conf": true, "lnk": true, "msi": true, "iso": true,
+	"img": true, "zip": true, "rar": true, "gz": true, "tar": true,
+	"elf": true, "so": true, "apk": true, "hta": true, "scr": true,
+}
+
+// reservedTLDs contains top-level domains that are reserved for private or
+// documentation use, domains under them are ignored unless private
+// indicators are kept.
+var reservedTLDs = map[string]bool{
+	"arpa": true, "example": true, "home": true, "internal": true,
+	"invalid": true, "lan": true, "local": true, "localhost": true,
+	"test": true,
+}
+
+// reservedPrefixes are the reserved IP address blocks that are not covered by
+// the methods of netip.Addr, like the ones used in documentation.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicIP returns true if addr is a global unicast address that is not
+// private nor reserved for special uses.
+func IsPublicIP(addr netip.Addr) bool {
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPublicDomain returns true if domain is not under a reserved top-level
+// domain or a second-level domain reserved for documentation.
+func isPublicDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if reservedTLDs[labels[len(labels)-1]] {
+		return false
+	}
+	if len(labels) >= 2 && labels[len(labels)-2] == "example" {
+		return false
+	}
+	return true
+}
+
+// isTLD returns true if label is a top-level domain in the public suffix list
+// maintained by ICANN, or one of the reserved top-level domains.
+func isTLD(label string) bool {
+	if reservedTLDs[label] {
+		return true
+	}
+	suffix, icann := publicsuffix.PublicSuffix(label)
+	return icann && suffix == label
+}
+
+// isAlphanumeric returns true if c is an ASCII letter or digit.
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDottedFragment returns true if text[start:end] is part of a longer
+// sequence of dot-separated words, like the "1.2.3.4" in "1.2.3.4.5". A dot
+// that ends a sentence doesn't count.
+func isDottedFragment(text string, start, end int) bool {
+	if start >= 2 && text[start-1] == '.' && isAlphanumeric(text[start-2]) {
+		return true
+	}
+	return end+1 < len(text) && text[end] == '.' && isAlphanumeric(text[end+1])
+}
+
+// isIPv6Char returns true if c is a character that can't appear next to an
+// IPv6 address.
+func isIPv6Char(c byte) bool {
+	return c == ':' || c == '_' ||
+		(c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// iocMatch is an IOC together with its position in the text.
+type iocMatch struct {
+	IOC
+	pos int
+}
+
+// ExtractIOCs returns the indicators of compromise found in text, in the order
+// in which they appear, after refanging it with Refang. The text is searched
+// for MD5, SHA-1 and SHA-256 hashes, URLs, domains, IPv4 and IPv6 addresses and
+// CVE identifiers. Domains that appear as part of a URL are not returned
+// separately, and domains whose last label is not a known top-level domain are
+// ignored. If keepPrivate is false, private and reserved IP addresses and
+// domains are ignored.
+func ExtractIOCs(text string, keepPrivate bool) []IOC {
+	text = Refang(text)
+	matches := make([]iocMatch, 0)
+
+	// Extract URLs first and blank them, so that the domains, addresses and
+	// hashes included in URLs are not reported on their own.
+	masked := []byte(text)
+	for _, loc := range iocURLRe.FindAllStringIndex(text, -1) {
+		url := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)]}")
+		matches = append(matches, iocMatch{IOC{IOCURL, url}, loc[0]})
+		for i := loc[0]; i < loc[1]; i++ {
+			masked[i] = ' '
+		}
+	}
+	text = string(masked)
+
+	for _, loc := range iocCVERe.FindAllStringIndex(text, -1) {
+		cve := strings.ToUpper(text[loc[0]:loc[1]])
+		matches = append(matches, iocMatch{IOC{IOCCVE, cve}, loc[0]})
+	}
+
+	for _, loc := range iocHashRe.FindAllStringIndex(text, -1) {
+		hash := strings.ToLower(text[loc[0]:loc[1]])
+		var t IOCType
+		switch len(hash) {
+		case 32:
+			t = IOCMD5
+		case 40:
+			t = IOCSHA1
+		case 64:
+			t = IOCSHA256
+		default:
+			continue
+		}
+		matches = append(matches, iocMatch{IOC{t, hash}, loc[0]})
+	}
+
+	for _, loc := range iocIPv4Re.FindAllStringIndex(text, -1) {
+		if isDottedFragment(text, loc[0], loc[1]) {
+			continue
+		}
+		addr, err := netip.ParseAddr(text[loc[0]:loc[1]])
+		if err != nil || (!keepPrivate && !IsPublicIP(addr)) {
+			continue
+		}
+		matches = append(matches, iocMatch{IOC{IOCIPv4, addr.String()}, loc[0]})
+	}
+
+	for _, loc := range iocIPv6Re.FindAllStringIndex(text, -1) {
+		// Discard candidates that are part of a longer word, like the "d::"
+		// in "std::string".
+		if (loc[0] > 0 && isIPv6Char(text[loc[0]-1])) ||
+			(loc[1] < len(text) && isIPv6Char(text[loc[1]])) {
+			continue
+		}
+		addr, err := netip.ParseAddr(text[loc[0]:loc[1]])
+		if err != nil || !addr.Is6() || addr.Is4In6() {
+			continue
+		}
+		if !keepPrivate && !IsPublicIP(addr) {
+			continue
+		}
+		matches = append(matches, iocMatch{IOC{IOCIPv6, addr.String()}, loc[0]})
+	}
+
+	for _, loc := range iocDomainRe.FindAllStringIndex(text, -1) {
+		if isDottedFragment(text, loc[0], loc[1]) {
+			continue
+		}
+		domain := strings.ToLower(text[loc[0]:loc[1]])
+		tld := domain[strings.LastIndex(domain, ".")+1:]
+		if nonTLDs[tld] || !isTLD(tld) || (!keepPrivate && !isPublicDomain(domain)) {
+			continue
+		}
+		matches = append(matches, iocMatch{IOC{IOCDomain, domain}, loc[0]})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].pos < matches[j].pos
+	})
+
+	iocs := make([]IOC, len(matches))
+	for i, m := range matches {
+		iocs[i] = m.IOC
+	}
+	return iocs
+}
+
+var (
+	analysisIDRe     = regexp.MustCompile(`^[fu]-[[:xdigit:]]{64}-\d+$`)
+	analysisBase64Re = regexp.MustCompile(`^[[:xdigit:]]{32}:\d+$`)
+	urlLikeRe        = regexp.MustCompile(`(?i)^(?:[a-z][a-z0-9+.-]*://|(?:[a-z0-9-]+\.)+[a-z0-9-]+(?::\d+)?/)`)
+)
+
+// DetectIOCType returns the type of the indicator s, which is expected to be
+// a single indicator, like a hash or a URL, and not free text. URLs are
+// recognized either by their scheme or by a path following the host. Besides
+// the types returned by ExtractIOCs, DetectIOCType recognizes analysis
+// identifiers. The second value returned is false if the type couldn't be
+// determined.
+func DetectIOCType(s string) (IOCType, bool) {
+	if analysisIDRe.MatchString(s) {
+		return IOCAnalysis, true
+	}
+	if m := iocHashRe.FindString(s); len(m) == len(s) {
+		switch len(s) {
+		case 32:
+			return IOCMD5, true
+		case 40:
+			return IOCSHA1, true
+		case 64:
+			return IOCSHA256, true
+		}
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		if addr.Unmap().Is4() {
+			return IOCIPv4, true
+		}
+		return IOCIPv6, true
+	}
+	if m := iocCVERe.FindString(s); len(m) == len(s) {
+		return IOCCVE, true
+	}
+	if urlLikeRe.MatchString(s) {
+		return IOCURL, true
+	}
+	if m := iocDomainRe.FindString(s); len(m) == len(s) {
+		return IOCDomain, true
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && analysisBase64Re.Match(b) {
+		return IOCAnalysis, true
+	}
+	return "", false
+}
+
+// ObjectPath returns the API path for the object corresponding to an indicator
+// of the given type, like "files/<hash>" for hashes. URLs are encoded with
+// base64 as required by the API, other identifiers are path-escaped so that
+// they can't be interpreted as a different path. The second value returned is
+// false if there is no object type for the indicator's type.
+func ObjectPath(t IOCType, ioc string) (string, bool) {
+	switch t {
+	case IOCMD5, IOCSHA1, IOCSHA256:
+		return "files/" + url.PathEscape(strings.ToLower(ioc)), true
+	case IOCURL:
+		return "urls/" + base64.RawURLEncoding.EncodeToString([]byte(ioc)), true
+	case IOCDomain:
+		return "domains/" + url.PathEscape(strings.ToLower(ioc)), true
+	case IOCIPv4, IOCIPv6:
+		return "ip_addresses/" + url.PathEscape(ioc), true
+	case IOCAnalysis:
+		return "analyses/" + url.PathEscape(ioc), true
+	}
+	return "", false
+}
+
+// IOCReader reads text from a StringReader and returns the indicators of
+// compromise found in it, as returned by ExtractIOCs. Each indicator is
+// returned only once, even if it appears multiple times in the text.
+type IOCReader struct {
+	// KeepPrivate indicates whether private and reserved IP addresses and
+	// domains must be returned too.
+	KeepPrivate bool
+
+	r       StringReader
+	types   map[IOCType]bool
+	seen    map[IOC]bool
+	pending []IOC
+}
+
+// NewIOCReader creates a new IOCReader that reads text from r and returns the
+// indicators of the given types. If no types are specified indicators of all
+// types are returned.
+func NewIOCReader(r StringReader, types ...IOCType) *IOCReader {
+	ir := &IOCReader{r: r, seen: make(map[IOC]bool)}
+	if len(types) > 0 {
+		ir.types = make(map[IOCType]bool)
+		for _, t := range types {
+			ir.types[t] = true
+		}
+	}
+	return ir
+}
+
+// ReadIOC returns the next indicator of compromise. When all the text has been
+// read ReadIOC returns an io.EOF error.
+func (ir *IOCReader) ReadIOC() (IOC, error) {
+	for len(ir.pending) == 0 {
+		s, err := ir.r.ReadString()
+		if s == "" && err != nil {
+			return IOC{}, err
+		}
+		for _, ioc := range ExtractIOCs(s, ir.KeepPrivate) {
+			if (ir.types == nil || ir.types[ioc.Type]) && !ir.seen[ioc] {
+				ir.seen[ioc] = true
+				ir.pending = append(ir.pending, ioc)
+			}
+		}
+	}
+	ioc := ir.pending[0]
+	ir.pending = ir.pending[1:]
+	return ioc, nil
+}
+
+// ReadString returns the value of the next indicator of compromise. When all
+// the text has been read ReadString returns an io.EOF error.
+func (ir *IOCReader) ReadString() (string, error) {
+	ioc, err := ir.ReadIOC()
+	return ioc.Value, err
+}
@@ -0,0 +1,134 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefang(t *testing.T) {
+	tests := map[string]string{
+		"hxxp://evil[.]com/a":     "http://evil.com/a",
+		"hXXps[:]//evil(dot)com":  "https://evil.com",
+		"fxp://files[.]evil[.]ru": "ftp://files.evil.ru",
+		"8.8.8(.)8":               "8.8.8.8",
+		"evil{dot}com":            "evil.com",
+		"nothing to do here.":     "nothing to do here.",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, utils.Refang(input), input)
+	}
+}
+
+const iocText = `Incident report for CVE-2021-44228 (see also cve-2023-1234).
+
+The dropper 44d88612fea8a8f36de82e1278abb02f (sha1
+3395856ce81f2b7382dee72602f798b642f14140) was downloaded from
+hxxps://malware[.]example-cdn[.]net/payload.exe?id=1, and saved as invoice.pdf.
+It connects to c2(dot)badguys[.]org, 45.33.32[.]156 and 2606:4700:4700::1111,
+and scans 10.0.0.1, 192.168.1.1, 127.0.0.1 and fe80::1. std::string is not an
+address, nor is 12:30:45. The payload SHA-256 is
+275A021BBFB6489E54D471899F7DB9D1663FC695EC2FE2A2C4538AABF651FD0F, which was
+also seen in files from evil.test and host.local. Again: 45.33.32.156.`
+
+func TestExtractIOCs(t *testing.T) {
+	iocs := utils.ExtractIOCs(iocText, false)
+	assert.Equal(t, []utils.IOC{
+		{Type: utils.IOCCVE, Value: "CVE-2021-44228"},
+		{Type: utils.IOCCVE, Value: "CVE-2023-1234"},
+		{Type: utils.IOCMD5, Value: "44d88612fea8a8f36de82e1278abb02f"},
+		{Type: utils.IOCSHA1, Value: "3395856ce81f2b7382dee72602f798b642f14140"},
+		{Type: utils.IOCURL, Value: "https://malware.example-cdn.net/payload.exe?id=1"},
+		{Type: utils.IOCDomain, Value: "c2.badguys.org"},
+		{Type: utils.IOCIPv4, Value: "45.33.32.156"},
+		{Type: utils.IOCIPv6, Value: "2606:4700:4700::1111"},
+		{Type: utils.IOCSHA256, Value: "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f"},
+		{Type: utils.IOCIPv4, Value: "45.33.32.156"},
+	}, iocs)
+
+	iocs = utils.ExtractIOCs("10.0.0.1 fe80::1 host.local", true)
+	assert.Equal(t, []utils.IOC{
+		{Type: utils.IOCIPv4, Value: "10.0.0.1"},
+		{Type: utils.IOCIPv6, Value: "fe80::1"},
+		{Type: utils.IOCDomain, Value: "host.local"},
+	}, iocs)
+
+	// Words separated by dots that are not domains, and addresses that are
+	// part of a longer dotted sequence, are ignored.
+	iocs = utils.ExtractIOCs(
+		"java.lang.NullPointerException at self.assertEqual, see README.md "+
+			"and version 1.2.3.4.5, but 8.8.8.8. and evil.xn--p1ai are fine.", false)
+	assert.Equal(t, []utils.IOC{
+		{Type: utils.IOCIPv4, Value: "8.8.8.8"},
+		{Type: utils.IOCDomain, Value: "evil.xn--p1ai"},
+	}, iocs)
+}
+
+func TestIOCReader(t *testing.T) {
+	hashes, err := utils.ParseIOCTypes([]string{"hash"})
+	assert.NoError(t, err)
+	r := utils.NewIOCReader(
+		utils.NewStringIOReader(strings.NewReader(iocText)), hashes...)
+	var result []string
+	for s, err := r.ReadString(); err == nil; s, err = r.ReadString() {
+		result = append(result, s)
+	}
+	assert.Equal(t, []string{
+		"44d88612fea8a8f36de82e1278abb02f",
+		"3395856ce81f2b7382dee72602f798b642f14140",
+		"275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f",
+	}, result)
+
+	// Duplicates are returned only once.
+	r = utils.NewIOCReader(utils.NewStringIOReader(strings.NewReader(iocText)))
+	count := 0
+	for _, err := r.ReadIOC(); err == nil; _, err = r.ReadIOC() {
+		count++
+	}
+	assert.Equal(t, 9, count)
+
+	_, err = utils.ParseIOCTypes([]string{"sha512"})
+	assert.Error(t, err)
+}
+
+func TestDetectIOCType(t *testing.T) {
+	tests := map[string]utils.IOCType{
+		"44d88612fea8a8f36de82e1278abb02f":                                 utils.IOCMD5,
+		"3395856ce81f2b7382dee72602f798b642f14140":                         utils.IOCSHA1,
+		"275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f": utils.IOCSHA256,
+		"https://www.virustotal.com":                                       utils.IOCURL,
+		"www.virustotal.com/gui/home":                                      utils.IOCURL,
+		"virustotal.com":                                                   utils.IOCDomain,
+		"8.8.8.8":                                                          utils.IOCIPv4,
+		"2001:4860:4860::8888":                                             utils.IOCIPv6,
+		"CVE-2021-44228":                                                   utils.IOCCVE,
+		"MDJiY2FiZmZmZmQxNmZlMGZjMjUwZjA4Y2FkOTVlMGM6MTU0NjQ1NDUyMA==":     utils.IOCAnalysis,
+		"f-8739c76e681f900923b900c9df0ef75cf421d39cabb54650c4b9ad19b6a76d85-1": utils.IOCAnalysis,
+	}
+	for input, expected := range tests {
+		t.Run(input, func(t *testing.T) {
+			detected, ok := utils.DetectIOCType(input)
+			assert.True(t, ok)
+			assert.Equal(t, expected, detected)
+		})
+	}
+	for _, input := range []string{"foo", "44d88612fea8a8f36de82e1278abb02", "1.2.3"} {
+		_, ok := utils.DetectIOCType(input)
+		assert.False(t, ok, input)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	vt "github.com/VirusTotal/vt-go"
+)
+
+// MaxIPExpand is the maximum number of addresses that a single CIDR block or
+// range can be expanded into, regardless of the limit chosen by the user.
+const MaxIPExpand = 65536
+
+// ErrTooManyAddresses is returned when a CIDR block or range contains more
+// addresses than allowed.
+var ErrTooManyAddresses = errors.New("too many addresses")
+
+// ExpandIPRange parses s as an IPv4 or IPv6 address, a CIDR block like
+// 203.0.113.0/28, or a range like 203.0.113.1-203.0.113.20 and returns the
+// addresses it contains. In IPv4 ranges the upper bound can be abbreviated to
+// its last octet, as in 203.0.113.1-20. If s contains more than max addresses
+// the returned error wraps ErrTooManyAddresses.
+func ExpandIPRange(s string, max int) ([]netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefix = prefix.Masked()
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits >= 31 || 1<<hostBits > max {
+			return nil, fmt.Errorf("%s: %w, more than %d", s, ErrTooManyAddresses, max)
+		}
+		addrs := make([]netip.Addr, 0, 1<<hostBits)
+		for a := prefix.Addr(); a.IsValid() && prefix.Contains(a); a = a.Next() {
+			addrs = append(addrs, a)
+		}
+		return addrs, nil
+	}
+	if lo, hi, found := strings.Cut(s, "-"); found {
+		first, err := parseAddr(lo)
+		if err != nil {
+			return nil, err
+		}
+		last, err := parseRangeEnd(first, strings.TrimSpace(hi))
+		if err != nil {
+			return nil, err
+		}
+		if first.Is4() != last.Is4() {
+			return nil, fmt.Errorf("%s: mixed IPv4 and IPv6 addresses", s)
+		}
+		if last.Less(first) {
+			return nil, fmt.Errorf("%s: %s is lower than %s", s, last, first)
+		}
+		addrs := make([]netip.Addr, 0)
+		for a := first; a.IsValid() && a.Compare(last) <= 0; a = a.Next() {
+			if len(addrs) == max {
+				return nil, fmt.Errorf("%s: %w, more than %d", s, ErrTooManyAddresses, max)
+			}
+			addrs = append(addrs, a)
+		}
+		return addrs, nil
+	}
+	addr, err := parseAddr(s)
+	if err != nil {
+		return nil, err
+	}
+	return []netip.Addr{addr}, nil
+}
+
+// parseAddr parses an IP address, removing the zone if any and converting
+// IPv4-mapped IPv6 addresses to IPv4.
+func parseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return addr, err
+	}
+	return addr.WithZone("").Unmap(), nil
+}
+
+// parseRangeEnd parses the upper bound of a range starting at first. For IPv4
+// ranges s can be either a full address or the address' last octet.
+func parseRangeEnd(first netip.Addr, s string) (netip.Addr, error) {
+	if first.Is4() && !strings.Contains(s, ".") {
+		octet, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid range end %q", s)
+		}
+		b := first.As4()
+		b[3] = byte(octet)
+		return netip.AddrFrom4(b), nil
+	}
+	return parseAddr(s)
+}
+
+// IPRangeReader reads strings from a StringReader and returns IP addresses,
+// expanding CIDR blocks and ranges into the individual addresses they contain.
+// Strings that are not IP addresses, CIDR blocks or ranges are ignored with a
+// warning. If a CIDR block or range contains more than the maximum number of
+// addresses ReadString returns an error wrapping ErrTooManyAddresses.
+type IPRangeReader struct {
+	r       StringReader
+	max     int
+	pending []netip.Addr
+	record  interface{}
+}
+
+// NewIPRangeReader creates a new IPRangeReader that reads strings from r and
+// expands each of them in at most max addresses.
+func NewIPRangeReader(r StringReader, max int) *IPRangeReader {
+	return &IPRangeReader{r: r, max: max}
+}
+
+// ReadString returns the next IP address. When all addresses have been
+// returned ReadString returns an io.EOF error.
+func (ir *IPRangeReader) ReadString() (string, error) {
+	for len(ir.pending) == 0 {
+		s, err := ir.r.ReadString()
+		if s == "" && err != nil {
+			return "", err
+		}
+		addrs, err := ExpandIPRange(s, ir.max)
+		if errors.Is(err, ErrTooManyAddresses) {
+			return "", err
+		} else if err != nil {
+			Warnf("ignoring %q: not an IP address, CIDR block or range", s)
+		}
+		ir.pending = addrs
+		if ir.record = recordOf(ir.r); ir.record == nil {
+			ir.record = s
+		}
+	}
+	addr := ir.pending[0]
+	ir.pending = ir.pending[1:]
+	return addr.String(), nil
+}
+
+// Record returns the record from which the last address was read if the
+// underlying StringReader is a RecordReader, if not, it returns the address,
+// CIDR block or range from which the address was expanded.
+func (ir *IPRangeReader) Record() interface{} {
+	return ir.record
+}
+
+// IPAggregation specifies how AggregateIPs groups IP addresses. If ASN is true
+// addresses are grouped by autonomous system, if not they are grouped by the
+// network prefixes with the lengths in Prefix4 and Prefix6.
+type IPAggregation struct {
+	ASN     bool
+	Prefix4 int
+	Prefix6 int
+}
+
+// ParseIPAggregation parses an aggregation specification, which can be either
+// "asn" or one or two prefix lengths separated by commas, like "/24" or
+// "/24,/48". The first prefix length applies to IPv4 addresses, the second one
+// to IPv6 addresses. IPv6 addresses are grouped per /64 by default.
+func ParseIPAggregation(s string) (IPAggregation, error) {
+	agg := IPAggregation{Prefix4: 24, Prefix6: 64}
+	if strings.EqualFold(s, "asn") {
+		agg.ASN = true
+		return agg, nil
+	}
+	lengths := strings.Split(s, ",")
+	if len(lengths) > 2 {
+		return agg, fmt.Errorf("invalid aggregation %q", s)
+	}
+	for i, l := range lengths {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(l), "/"))
+		if err != nil || n < 0 || (i == 0 && n > 32) || n > 128 {
+			return agg, fmt.Errorf("invalid aggregation %q, use \"asn\", \"/24\" or \"/24,/48\"", s)
+		}
+		if i == 0 {
+			agg.Prefix4 = n
+		} else {
+			agg.Prefix6 = n
+		}
+	}
+	return agg, nil
+}
+
+// key returns the group an IP address object belongs to.
+func (a IPAggregation) key(obj *vt.Object) string {
+	if a.ASN {
+		if asn, err := obj.GetInt64("asn"); err == nil && asn != 0 {
+			return fmt.Sprintf("AS%d", asn)
+		}
+		return "unknown"
+	}
+	addr, err := parseAddr(obj.ID())
+	if err != nil {
+		return "unknown"
+	}
+	bits := a.Prefix4
+	if addr.Is6() {
+		bits = a.Prefix6
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.String()
+}
+
+// AggregateIPs groups IP address objects as specified by agg and returns a
+// summary for each group, in the order in which groups first appear in objs.
+// Each summary contains the number of addresses in the group, how many of them
+// are flagged as malicious or suspicious by at least one engine, the highest
+// number of malicious verdicts, the countries and the flagged addresses.
+func AggregateIPs(objs []*vt.Object, agg IPAggregation) []map[string]interface{} {
+	type group struct {
+		m         map[string]interface{}
+		countries map[string]bool
+		flagged   []string
+	}
+	groups := make(map[string]*group)
+	result := make([]map[string]interface{}, 0)
+	for _, obj := range objs {
+		key := agg.key(obj)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				m: map[string]interface{}{
+					"group":         key,
+					"addresses":     0,
+					"malicious":     0,
+					"suspicious":    0,
+					"max_malicious": int64(0),
+				},
+				countries: make(map[string]bool),
+				flagged:   make([]string, 0),
+			}
+			if agg.ASN {
+				g.m["as_owner"], _ = obj.GetString("as_owner")
+			}
+			groups[key] = g
+			result = append(result, g.m)
+		}
+		g.m["addresses"] = g.m["addresses"].(int) + 1
+		malicious, _ := obj.GetInt64("last_analysis_stats.malicious")
+		suspicious, _ := obj.GetInt64("last_analysis_stats.suspicious")
+		if malicious > 0 {
+			g.m["malicious"] = g.m["malicious"].(int) + 1
+			g.flagged = append(g.flagged, obj.ID())
+		} else if suspicious > 0 {
+			g.flagged = append(g.flagged, obj.ID())
+		}
+		if suspicious > 0 {
+			g.m["suspicious"] = g.m["suspicious"].(int) + 1
+		}
+		if malicious > g.m["max_malicious"].(int64) {
+			g.m["max_malicious"] = malicious
+		}
+		if country, _ := obj.GetString("country"); country != "" {
+			g.countries[country] = true
+		}
+	}
+	for _, g := range groups {
+		countries := make([]string, 0, len(g.countries))
+		for c := range g.countries {
+			countries = append(countries, c)
+		}
+		sort.Strings(countries)
+		g.m["countries"] = countries
+		g.m["flagged"] = g.flagged
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandIPRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"8.8.8.8", []string{"8.8.8.8"}},
+		{"2001:4860:4860::8888", []string{"2001:4860:4860::8888"}},
+		{"::ffff:192.0.2.1", []string{"192.0.2.1"}},
+		{"203.0.113.5/30", []string{"203.0.113.4", "203.0.113.5", "203.0.113.6", "203.0.113.7"}},
+		{"2001:db8::/127", []string{"2001:db8::", "2001:db8::1"}},
+		{"198.51.100.254-198.51.101.1", []string{"198.51.100.254", "198.51.100.255", "198.51.101.0", "198.51.101.1"}},
+		{"198.51.100.1-3", []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"}},
+		{"2001:db8::1 - 2001:db8::2", []string{"2001:db8::1", "2001:db8::2"}},
+	}
+	for _, test := range tests {
+		addrs, err := utils.ExpandIPRange(test.input, 16)
+		assert.NoError(t, err, test.input)
+		result := make([]string, 0)
+		for _, a := range addrs {
+			result = append(result, a.String())
+		}
+		assert.Equal(t, test.expected, result, test.input)
+	}
+
+	for _, input := range []string{"203.0.113.0/24", "2001:db8::/64", "10.0.0.0-10.0.1.0"} {
+		_, err := utils.ExpandIPRange(input, 16)
+		assert.True(t, errors.Is(err, utils.ErrTooManyAddresses), input)
+	}
+
+	for _, input := range []string{"foo", "1.2.3", "10.0.0.5-10.0.0.1", "10.0.0.1-2001:db8::1", "10.0.0.1-300"} {
+		_, err := utils.ExpandIPRange(input, 16)
+		assert.Error(t, err, input)
+		assert.False(t, errors.Is(err, utils.ErrTooManyAddresses), input)
+	}
+}
+
+func TestIPRangeReader(t *testing.T) {
+	r := utils.NewIPRangeReader(utils.NewStringArrayReader(
+		[]string{"8.8.8.8", "not an ip", "192.0.2.0/31", "::1"}), 4)
+	var result []string
+	for s, err := r.ReadString(); err == nil; s, err = r.ReadString() {
+		result = append(result, s)
+	}
+	assert.Equal(t, []string{"8.8.8.8", "192.0.2.0", "192.0.2.1", "::1"}, result)
+
+	r = utils.NewIPRangeReader(utils.NewStringArrayReader(
+		[]string{"8.8.8.8", "192.0.2.0/24"}), 4)
+	s, err := r.ReadString()
+	assert.Equal(t, "8.8.8.8", s)
+	assert.NoError(t, err)
+	_, err = r.ReadString()
+	assert.True(t, errors.Is(err, utils.ErrTooManyAddresses))
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func newIPObject(ip string, asn int64, owner, country string, malicious, suspicious int64) *vt.Object {
+	obj := vt.NewObjectWithID("ip_address", ip)
+	obj.SetInt64("asn", asn)
+	obj.SetString("as_owner", owner)
+	obj.SetString("country", country)
+	obj.Set("last_analysis_stats", map[string]interface{}{
+		"malicious":  malicious,
+		"suspicious": suspicious,
+	})
+	return obj
+}
+
+func TestAggregateIPs(t *testing.T) {
+	objs := []*vt.Object{
+		newIPObject("203.0.113.1", 64500, "Example", "US", 3, 0),
+		newIPObject("203.0.113.2", 64500, "Example", "NL", 0, 1),
+		newIPObject("198.51.100.1", 64500, "Example", "US", 0, 0),
+		newIPObject("2001:db8::1", 64501, "Other", "DE", 5, 2),
+	}
+
+	agg, err := utils.ParseIPAggregation("/24")
+	assert.NoError(t, err)
+	groups := utils.AggregateIPs(objs, agg)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, "203.0.113.0/24", groups[0]["group"])
+	assert.Equal(t, 2, groups[0]["addresses"])
+	assert.Equal(t, 1, groups[0]["malicious"])
+	assert.Equal(t, 1, groups[0]["suspicious"])
+	assert.Equal(t, int64(3), groups[0]["max_malicious"])
+	assert.Equal(t, []string{"NL", "US"}, groups[0]["countries"])
+	assert.Equal(t, []string{"203.0.113.1", "203.0.113.2"}, groups[0]["flagged"])
+	assert.Equal(t, "198.51.100.0/24", groups[1]["group"])
+	assert.Equal(t, []string{}, groups[1]["flagged"])
+	assert.Equal(t, "2001:db8::/64", groups[2]["group"])
+
+	agg, err = utils.ParseIPAggregation("asn")
+	assert.NoError(t, err)
+	groups = utils.AggregateIPs(objs, agg)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "AS64500", groups[0]["group"])
+	assert.Equal(t, "Example", groups[0]["as_owner"])
+	assert.Equal(t, 3, groups[0]["addresses"])
+	assert.Equal(t, "AS64501", groups[1]["group"])
+
+	agg, err = utils.ParseIPAggregation("/16,/32")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.IPAggregation{Prefix4: 16, Prefix6: 32}, agg)
+
+	for _, s := range []string{"/33", "foo", "/24,/48,/64", "/24,/129"} {
+		_, err = utils.ParseIPAggregation(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	vt "github.com/VirusTotal/vt-go"
+)
+
+// Journal keeps track of the items processed by a Coordinator. Items that
+// were completely processed are recorded as completed, items whose processing
+// failed are recorded as failed, while items that were not processed, or whose
+// processing was aborted, are recorded as pending. A journal saved to a file
+// can be loaded later for resuming the work with the failed and pending items.
+//
+// If the work was interrupted before all the input was read, Cursor contains
+// the position where the input can be continued, for inputs that have one,
+// like search results. For other inputs Truncated is true, indicating that
+// the items following the pending ones are not recorded in the journal.
+type Journal struct {
+	Command   string   `json:"command"`
+	Completed []string `json:"completed"`
+	Failed    []string `json:"failed"`
+	Pending   []string `json:"pending"`
+	Cursor    string   `json:"cursor,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+	mu        sync.Mutex
+}
+
+// NewJournal creates a new journal for the given command.
+func NewJournal(command string) *Journal {
+	return &Journal{
+		Command:   command,
+		Completed: make([]string, 0),
+		Failed:    make([]string, 0),
+		Pending:   make([]string, 0)}
+}
+
+// LoadJournal loads a journal from a file previously written with Save.
+func LoadJournal(filename string) (*Journal, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	j := NewJournal("")
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("invalid journal file %s: %v", filename, err)
+	}
+	return j, nil
+}
+
+// JournalItems is implemented by items that group multiple items, like a
+// batch of hashes, which are recorded individually in the journal.
+type JournalItems interface {
+	JournalItems() []string
+}
+
+// journalItems returns the strings that represent an item in the journal.
+func journalItems(item interface{}) []string {
+	switch v := item.(type) {
+	case string:
+		return []string{v}
+	case *vt.Object:
+		return []string{v.ID()}
+	case JournalItems:
+		return v.JournalItems()
+	case fmt.Stringer:
+		return []string{v.String()}
+	}
+	return nil
+}
+
+// Complete records an item as completed.
+func (j *Journal) Complete(item interface{}) {
+	j.mu.Lock()
+	j.Completed = append(j.Completed, journalItems(item)...)
+	j.mu.Unlock()
+}
+
+// Fail records an item as failed.
+func (j *Journal) Fail(item interface{}) {
+	j.mu.Lock()
+	j.Failed = append(j.Failed, journalItems(item)...)
+	j.mu.Unlock()
+}
+
+// Pend records an item as pending.
+func (j *Journal) Pend(item interface{}) {
+	j.mu.Lock()
+	j.Pending = append(j.Pending, journalItems(item)...)
+	j.mu.Unlock()
+}
+
+// TakePending returns the failed and pending items, in that order, and
+// removes them from the journal, so that they can be processed again.
+func (j *Journal) TakePending() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	pending := append(j.Failed, j.Pending...)
+	j.Failed = make([]string, 0)
+	j.Pending = make([]string, 0)
+	return pending
+}
+
+// Save writes the journal to a file.
+func (j *Journal) Save(filename string) error {
+	j.mu.Lock()
+	data, err := json.MarshalIndent(j, "", "  ")
+	j.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,179 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+// Log levels, from the most to the least severe.
+const (
+	LogError LogLevel = iota
+	LogWarning
+	LogInfo
+	LogDebug
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogError:
+		return "ERROR"
+	case LogWarning:
+		return "WARNING"
+	case LogInfo:
+		return "INFO"
+	}
+	return "DEBUG"
+}
+
+// Logger writes log messages to stderr and optionally to a log file. Messages
+// are written to stderr as they are, without any decoration, and only if their
+// level is equal or more severe than the logger's level. All messages are
+// written to the log file, prefixed by a timestamp and their level. Every
+// occurrence of the strings registered with Redact are replaced with a
+// placeholder before writing the message.
+type Logger struct {
+	Level  LogLevel
+	stderr io.Writer
+	file   io.WriteCloser
+	redact []string
+	// redactRe contains the regular expressions that match short secrets in
+	// the positions where secrets are expected.
+	redactRe []*regexp.Regexp
+	mu       sync.Mutex
+}
+
+var logger = &Logger{Level: LogWarning, stderr: os.Stderr}
+
+// SetupLogging configures the logger used by the program. level determines
+// which messages are written to stderr. If logFile is not empty all messages,
+// regardless of their level, are appended to that file.
+func SetupLogging(level LogLevel, logFile string) error {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Level = level
+	if logger.file != nil {
+		logger.file.Close()
+		logger.file = nil
+	}
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		logger.file = f
+	}
+	return nil
+}
+
+// LogFileEnabled returns true if messages are being written to a log file.
+func LogFileEnabled() bool {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	return logger.file != nil
+}
+
+// minRedactLength is the minimum length of the secrets that are redacted
+// wherever they appear, replacing shorter strings would corrupt unrelated parts
+// of the messages.
+const minRedactLength = 6
+
+// shortSecretPatterns are the positions where short secrets are redacted, the
+// %s is replaced with the secret. They match the secret as the value of a
+// parameter in a query string, as the password in a URL, and as the value of
+// a header.
+var shortSecretPatterns = []string{
+	`([?&][^=&\s]*=)%s([&#\s]|$)`,
+	`(//[^/@\s]*:)%s(@)`,
+	`(: )%s($)`,
+}
+
+// Redact registers a secret, like an API key, that must never appear in the
+// logs. Secrets shorter than a few characters are redacted only where secrets
+// are expected, like query strings, URL passwords and header values.
+func Redact(secret string) {
+	if secret == "" {
+		return
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(secret) >= minRedactLength {
+		logger.redact = append(logger.redact, secret)
+		return
+	}
+	for _, p := range shortSecretPatterns {
+		logger.redactRe = append(logger.redactRe,
+			regexp.MustCompile(fmt.Sprintf(p, regexp.QuoteMeta(secret))))
+	}
+}
+
+// Redacted returns s with all the secrets registered with Redact replaced
+// with a placeholder.
+func Redacted(s string) string {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	return logger.redacted(s)
+}
+
+func (l *Logger) redacted(s string) string {
+	for _, secret := range l.redact {
+		s = strings.ReplaceAll(s, secret, "<redacted>")
+	}
+	for _, re := range l.redactRe {
+		s = re.ReplaceAllString(s, "${1}<redacted>${2}")
+	}
+	return s
+}
+
+func (l *Logger) log(level LogLevel, format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	msg := l.redacted(fmt.Sprintf(format, a...))
+	if level <= l.Level {
+		fmt.Fprintln(l.stderr, msg)
+	}
+	if l.file != nil {
+		fmt.Fprintf(l.file, "%s %-7s %s\n",
+			time.Now().Format(time.RFC3339Nano), level, strings.TrimSpace(msg))
+	}
+}
+
+// Errorf logs a message with level LogError.
+func Errorf(format string, a ...interface{}) {
+	logger.log(LogError, format, a...)
+}
+
+// Warnf logs a message with level LogWarning.
+func Warnf(format string, a ...interface{}) {
+	logger.log(LogWarning, format, a...)
+}
+
+// Infof logs a message with level LogInfo.
+func Infof(format string, a ...interface{}) {
+	logger.log(LogInfo, format, a...)
+}
+
+// Debugf logs a message with level LogDebug.
+func Debugf(format string, a ...interface{}) {
+	logger.log(LogDebug, format, a...)
+}
@@ -0,0 +1,91 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UpdateSHA256Sums adds entries to a manifest file in the format used by
+// sha256sum, where each line contains a SHA-256 and a file path relative to
+// the directory where the manifest is. sums maps paths to their SHA-256.
+// Entries for paths that are already in the manifest are replaced, and the
+// entries are sorted by path. The file is created if it doesn't exist.
+func UpdateSHA256Sums(filename string, sums map[string]string) error {
+	entries, err := readSHA256Sums(filename)
+	if err != nil {
+		return err
+	}
+	for path, sum := range sums {
+		entries[filepath.ToSlash(path)] = sum
+	}
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Write a new file and rename it, so that the manifest is not left
+	// truncated if something goes wrong.
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	for _, path := range paths {
+		fmt.Fprintf(w, "%s  %s\n", entries[path], path)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// readSHA256Sums reads the entries in a manifest written by UpdateSHA256Sums.
+// If the file doesn't exist no entries are returned.
+func readSHA256Sums(filename string) (map[string]string, error) {
+	entries := make(map[string]string)
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		sum, path, ok := strings.Cut(scanner.Text(), " ")
+		// The separator is two spaces for text mode or a space and an
+		// asterisk for binary mode.
+		if !ok || len(sum) != 64 || len(path) < 2 || (path[0] != ' ' && path[0] != '*') {
+			if strings.TrimSpace(scanner.Text()) != "" {
+				return nil, fmt.Errorf("%s:%d: invalid line", filename, line)
+			}
+			continue
+		}
+		entries[path[1:]] = sum
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,51 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSHA256Sums(t *testing.T) {
+	a := strings.Repeat("a", 64)
+	b := strings.Repeat("b", 64)
+	c := strings.Repeat("c", 64)
+	filename := filepath.Join(t.TempDir(), "SHA256SUMS")
+
+	assert.NoError(t, utils.UpdateSHA256Sums(filename, map[string]string{
+		"zz/sample":  a,
+		"sample.exe": b,
+	}))
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, b+"  sample.exe\n"+a+"  zz/sample\n", string(content))
+
+	// Existing entries are kept, and replaced if the path is the same.
+	assert.NoError(t, utils.UpdateSHA256Sums(filename, map[string]string{
+		"sample.exe": c,
+		"other":      a,
+	}))
+	content, err = os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, a+"  other\n"+c+"  sample.exe\n"+a+"  zz/sample\n", string(content))
+
+	assert.NoError(t, os.WriteFile(filename, []byte("garbage\n"), 0o644))
+	assert.Error(t, utils.UpdateSHA256Sums(filename, map[string]string{"other": a}))
+}
@@ -0,0 +1,155 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// MitreSignature is a sandbox signature that maps a behaviour observed during
+// the execution of a file to a MITRE ATT&CK technique.
+type MitreSignature struct {
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// MitreTechnique is a MITRE ATT&CK technique, together with the signatures
+// that matched it.
+type MitreTechnique struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Link        string           `json:"link"`
+	Signatures  []MitreSignature `json:"signatures"`
+}
+
+// MitreTactic is a MITRE ATT&CK tactic with the techniques observed for it.
+type MitreTactic struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Link        string           `json:"link"`
+	Techniques  []MitreTechnique `json:"techniques"`
+}
+
+// MitreTree contains the MITRE ATT&CK tactics and techniques observed by a
+// sandbox, as returned by the files/{id}/behaviour_mitre_trees endpoint.
+type MitreTree struct {
+	Tactics []MitreTactic `json:"tactics"`
+}
+
+// severityRanks ranks the severities of signatures, higher values are more
+// severe.
+var severityRanks = map[string]int{
+	"info":   1,
+	"low":    2,
+	"medium": 3,
+	"high":   4,
+}
+
+// severityName returns the short name of a severity like
+// "IMPACT_SEVERITY_HIGH", which is "high".
+func severityName(s string) string {
+	return strings.ToLower(strings.TrimPrefix(s, "IMPACT_SEVERITY_"))
+}
+
+// Severity returns the highest severity among the technique's signatures, like
+// "high", or an empty string if it's unknown.
+func (t *MitreTechnique) Severity() string {
+	severity := ""
+	for _, s := range t.Signatures {
+		if name := severityName(s.Severity); severityRanks[name] > severityRanks[severity] {
+			severity = name
+		}
+	}
+	return severity
+}
+
+// colorSeverity returns the severity colored according to its rank.
+func colorSeverity(severity string) string {
+	switch severity {
+	case "high":
+		return color.RedString(severity)
+	case "medium":
+		return color.YellowString(severity)
+	}
+	return severity
+}
+
+// PrintMitreTrees writes the MITRE ATT&CK trees of multiple sandboxes to w,
+// with a tree of tactics and techniques for each sandbox, in alphabetical
+// order. Techniques are followed by the highest severity of their signatures.
+// If signatures is true the signatures are included below each technique.
+// Sandboxes that didn't observe any tactic are omitted.
+func PrintMitreTrees(w io.Writer, trees map[string]MitreTree, signatures bool) {
+	sandboxes := make([]string, 0, len(trees))
+	for sandbox, tree := range trees {
+		if len(tree.Tactics) > 0 {
+			sandboxes = append(sandboxes, sandbox)
+		}
+	}
+	sort.Strings(sandboxes)
+	for i, sandbox := range sandboxes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, color.New(color.Bold).Sprint(sandbox))
+		tactics := trees[sandbox].Tactics
+		for j, tactic := range tactics {
+			tacticPrefix := treeBranch(j == len(tactics)-1)
+			fmt.Fprintf(w, "%s%s %s\n", tacticPrefix, tactic.ID, tactic.Name)
+			for k, technique := range tactic.Techniques {
+				lastTechnique := k == len(tactic.Techniques)-1
+				prefix := treeIndent(j == len(tactics)-1) + treeBranch(lastTechnique)
+				line := fmt.Sprintf("%s%s %s", prefix, technique.ID, technique.Name)
+				if severity := technique.Severity(); severity != "" {
+					line = fmt.Sprintf("%s [%s]", line, colorSeverity(severity))
+				}
+				fmt.Fprintln(w, line)
+				if !signatures {
+					continue
+				}
+				for l, s := range technique.Signatures {
+					prefix := treeIndent(j == len(tactics)-1) +
+						treeIndent(lastTechnique) +
+						treeBranch(l == len(technique.Signatures)-1)
+					fmt.Fprintf(w, "%s%s [%s]\n",
+						prefix, s.Description, colorSeverity(severityName(s.Severity)))
+				}
+			}
+		}
+	}
+}
+
+// treeBranch returns the prefix for an item in a tree, which depends on
+// whether the item is the last one in its level.
+func treeBranch(last bool) string {
+	if last {
+		return "└── "
+	}
+	return "├── "
+}
+
+// treeIndent returns the indentation for the children of an item in a tree.
+func treeIndent(last bool) string {
+	if last {
+		return "    "
+	}
+	return "│   "
+}
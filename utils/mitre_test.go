@@ -0,0 +1,64 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const mitreTrees = `{
+  "Zenbox": {"tactics": [
+    {"id": "TA0002", "name": "Execution", "techniques": [
+      {"id": "T1059", "name": "Command and Scripting Interpreter", "signatures": [
+        {"severity": "IMPACT_SEVERITY_LOW", "description": "Starts cmd.exe"},
+        {"severity": "IMPACT_SEVERITY_HIGH", "description": "Runs encoded PowerShell"}]},
+      {"id": "T1106", "name": "Native API", "signatures": []}]},
+    {"id": "TA0011", "name": "Command and Control", "techniques": [
+      {"id": "T1071", "name": "Application Layer Protocol", "signatures": [
+        {"severity": "IMPACT_SEVERITY_MEDIUM", "description": "Uses HTTP"}]}]}]},
+  "C2AE": {"tactics": []}
+}`
+
+func TestPrintMitreTrees(t *testing.T) {
+	var trees map[string]utils.MitreTree
+	assert.NoError(t, json.Unmarshal([]byte(mitreTrees), &trees))
+
+	buf := &bytes.Buffer{}
+	utils.PrintMitreTrees(buf, trees, false)
+	assert.Equal(t, `Zenbox
+├── TA0002 Execution
+│   ├── T1059 Command and Scripting Interpreter [high]
+│   └── T1106 Native API
+└── TA0011 Command and Control
+    └── T1071 Application Layer Protocol [medium]
+`, buf.String())
+
+	buf.Reset()
+	utils.PrintMitreTrees(buf, trees, true)
+	assert.Equal(t, `Zenbox
+├── TA0002 Execution
+│   ├── T1059 Command and Scripting Interpreter [high]
+│   │   ├── Starts cmd.exe [low]
+│   │   └── Runs encoded PowerShell [high]
+│   └── T1106 Native API
+└── TA0011 Command and Control
+    └── T1071 Application Layer Protocol [medium]
+        └── Uses HTTP [medium]
+`, buf.String())
+}
@@ -0,0 +1,104 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nameTemplateField matches the placeholders in a name template.
+var nameTemplateField = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validNameTemplateField matches the valid field names in placeholders.
+var validNameTemplateField = regexp.MustCompile(`^[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*$`)
+
+// NameTemplate is a template for file names, like "{sha256}.{type_extension}",
+// where the placeholders between braces are replaced with the values of the
+// corresponding fields.
+type NameTemplate struct {
+	template string
+	fields   []string
+}
+
+// ParseNameTemplate parses a name template. Field names in placeholders can
+// contain letters, digits, underscores and dots.
+func ParseNameTemplate(template string) (*NameTemplate, error) {
+	if template == "" {
+		return nil, errors.New("empty name template")
+	}
+	t := &NameTemplate{template: template}
+	seen := make(map[string]bool)
+	for _, m := range nameTemplateField.FindAllStringSubmatch(template, -1) {
+		if !validNameTemplateField.MatchString(m[1]) {
+			return nil, fmt.Errorf("invalid field %q in name template %q", m[0], template)
+		}
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			t.fields = append(t.fields, m[1])
+		}
+	}
+	if rest := nameTemplateField.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return nil, fmt.Errorf("unbalanced braces in name template %q", template)
+	}
+	return t, nil
+}
+
+// Fields returns the names of the fields used in the template, in the order
+// they first appear.
+func (t *NameTemplate) Fields() []string {
+	return t.fields
+}
+
+// Expand returns the name resulting from replacing the placeholders in the
+// template with the values returned by value for each field. Path separators
+// in values are replaced with underscores, so that values can't change the
+// directory where the file is put. If a value is empty the placeholder is
+// removed together with the dot preceding it, if any, so that
+// "{sha256}.{type_extension}" becomes just the SHA-256 for files without type
+// extension.
+func (t *NameTemplate) Expand(value func(field string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range nameTemplateField.FindAllStringSubmatchIndex(t.template, -1) {
+		prefix := t.template[last:m[0]]
+		v := SanitizeFileName(value(t.template[m[2]:m[3]]))
+		if v == "" {
+			prefix = strings.TrimSuffix(prefix, ".")
+		}
+		b.WriteString(prefix)
+		b.WriteString(v)
+		last = m[1]
+	}
+	b.WriteString(t.template[last:])
+	return b.String()
+}
+
+// SanitizeFileName replaces the characters that can't be part of a file name,
+// like path separators, so that s can be used as the name of a file without
+// referring to a different directory.
+func SanitizeFileName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameTemplate(t *testing.T) {
+	tmpl, err := utils.ParseNameTemplate("{type_tag}/{sha256}.{type_extension}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"type_tag", "sha256", "type_extension"}, tmpl.Fields())
+
+	values := map[string]string{"type_tag": "peexe", "sha256": "abcd", "type_extension": "exe"}
+	assert.Equal(t, "peexe/abcd.exe", tmpl.Expand(func(f string) string { return values[f] }))
+
+	// Empty values are removed together with the preceding dot.
+	delete(values, "type_extension")
+	assert.Equal(t, "peexe/abcd", tmpl.Expand(func(f string) string { return values[f] }))
+
+	// Values can't add path components.
+	values["type_tag"] = "../x/y"
+	assert.Equal(t, ".._x_y/abcd", tmpl.Expand(func(f string) string { return values[f] }))
+	values["type_tag"] = ".."
+	assert.Equal(t, "_/abcd", tmpl.Expand(func(f string) string { return values[f] }))
+
+	tmpl, err = utils.ParseNameTemplate("sample")
+	assert.NoError(t, err)
+	assert.Empty(t, tmpl.Fields())
+	assert.Equal(t, "sample", tmpl.Expand(func(f string) string { return "x" }))
+
+	for _, invalid := range []string{"", "{sha256", "sha256}", "{}", "{a b}", "{{sha256}}"} {
+		_, err = utils.ParseNameTemplate(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "abcd_Zenbox.pcap", utils.SanitizeFileName("abcd_Zenbox.pcap"))
+	assert.Equal(t, ".._.._etc_passwd", utils.SanitizeFileName("../../etc/passwd"))
+	assert.Equal(t, "a_b_c", utils.SanitizeFileName("a\\b\nc"))
+	assert.Equal(t, "_", utils.SanitizeFileName(".."))
+}
@@ -0,0 +1,207 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/fatih/color"
+)
+
+// analysisStats contains the names of the counters in last_analysis_stats
+// that can be used in a Policy.
+var analysisStats = []string{
+	"malicious", "suspicious", "harmless", "undetected", "timeout",
+	"confirmed-timeout", "failure", "type-unsupported",
+}
+
+// condition is a comparison between a counter in last_analysis_stats and a
+// number, like malicious>=3.
+type condition struct {
+	stat  string
+	op    string
+	value int64
+}
+
+func (c condition) eval(stats map[string]int64) bool {
+	n := stats[c.stat]
+	switch c.op {
+	case ">=":
+		return n >= c.value
+	case ">":
+		return n > c.value
+	case "<=":
+		return n <= c.value
+	case "<":
+		return n < c.value
+	case "!=":
+		return n != c.value
+	default:
+		return n == c.value
+	}
+}
+
+var (
+	orRe        = regexp.MustCompile(`(?i)\s+or\s+`)
+	andRe       = regexp.MustCompile(`(?i)\s+and\s+`)
+	conditionRe = regexp.MustCompile(`^([a-z-]+)\s*(>=|<=|==|!=|=|>|<)\s*(\d+)$`)
+)
+
+// Policy decides whether an object must be flagged according to its
+// last_analysis_stats. A policy is a set of conditions joined with "and" and
+// "or", like "malicious>=3 or suspicious>=5 and harmless<10", where "and" has
+// precedence over "or".
+type Policy struct {
+	expr string
+	// Disjunction of conjunctions.
+	terms [][]condition
+}
+
+// ParsePolicy parses a policy expression.
+func ParsePolicy(expr string) (*Policy, error) {
+	p := &Policy{expr: strings.TrimSpace(expr)}
+	if p.expr == "" {
+		return nil, fmt.Errorf("empty policy")
+	}
+	for _, or := range orRe.Split(p.expr, -1) {
+		term := make([]condition, 0)
+		for _, and := range andRe.Split(strings.TrimSpace(or), -1) {
+			m := conditionRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(and)))
+			if m == nil {
+				return nil, fmt.Errorf("invalid condition %q in policy %q", and, expr)
+			}
+			if !isAnalysisStat(m[1]) {
+				return nil, fmt.Errorf("unknown counter %q in policy %q, use %s",
+					m[1], expr, strings.Join(analysisStats, ", "))
+			}
+			value, _ := strconv.ParseInt(m[3], 10, 64)
+			term = append(term, condition{stat: m[1], op: m[2], value: value})
+		}
+		p.terms = append(p.terms, term)
+	}
+	return p, nil
+}
+
+func isAnalysisStat(name string) bool {
+	for _, s := range analysisStats {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the policy expression.
+func (p *Policy) String() string {
+	return p.expr
+}
+
+// AnalysisStats returns the counters in the last_analysis_stats attribute of
+// obj. It returns an error if the object doesn't have that attribute.
+func AnalysisStats(obj *vt.Object) (map[string]int64, error) {
+	v, err := obj.Get("last_analysis_stats")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%s has no analysis stats", obj.ID())
+	}
+	stats := make(map[string]int64)
+	for _, name := range analysisStats {
+		stats[name], _ = obj.GetInt64("last_analysis_stats." + name)
+	}
+	return stats, nil
+}
+
+// Eval returns true if the given stats must be flagged according to the
+// policy.
+func (p *Policy) Eval(stats map[string]int64) bool {
+	for _, term := range p.terms {
+		match := true
+		for _, c := range term {
+			if !c.eval(stats) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// Gate evaluates a Policy against the objects obtained for the items processed
+// by a command, keeping count of how many of them were clean, flagged or
+// failed, and printing a verdict line for each of them.
+type Gate struct {
+	Policy *Policy
+	// Out is where the verdict lines are printed, os.Stderr by default.
+	Out io.Writer
+
+	mu      sync.Mutex
+	clean   int
+	flagged int
+	failed  int
+}
+
+// NewGate returns a new Gate for the given policy.
+func NewGate(p *Policy) *Gate {
+	return &Gate{Policy: p, Out: os.Stderr}
+}
+
+// Check evaluates the policy for an item, where obj is the object obtained
+// for the item, or err the error occurred while obtaining it. Returns true if
+// the item was flagged.
+func (g *Gate) Check(item string, obj *vt.Object, err error) bool {
+	var stats map[string]int64
+	if err == nil && obj == nil {
+		err = fmt.Errorf("no analysis results")
+	}
+	if err == nil {
+		stats, err = AnalysisStats(obj)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err != nil {
+		g.failed++
+		fmt.Fprintf(g.Out, "%s %s: %v\n", color.YellowString("error  "), item, err)
+		return false
+	}
+	flagged := g.Policy.Eval(stats)
+	verdict := color.GreenString("clean  ")
+	if flagged {
+		g.flagged++
+		verdict = color.RedString("flagged")
+	} else {
+		g.clean++
+	}
+	fmt.Fprintf(g.Out, "%s %s malicious=%d suspicious=%d harmless=%d undetected=%d\n",
+		verdict, item, stats["malicious"], stats["suspicious"], stats["harmless"], stats["undetected"])
+	return flagged
+}
+
+// Counts returns the number of items that were clean, flagged and failed.
+func (g *Gate) Counts() (clean, flagged, failed int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.clean, g.flagged, g.failed
+}
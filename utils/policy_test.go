@@ -0,0 +1,71 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	vt "github.com/VirusTotal/vt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := utils.ParsePolicy("malicious>=3 or suspicious >= 5 AND harmless<10")
+	assert.NoError(t, err)
+	assert.True(t, p.Eval(map[string]int64{"malicious": 3}))
+	assert.False(t, p.Eval(map[string]int64{"malicious": 2, "suspicious": 4}))
+	assert.True(t, p.Eval(map[string]int64{"suspicious": 5, "harmless": 9}))
+	assert.False(t, p.Eval(map[string]int64{"suspicious": 5, "harmless": 10}))
+
+	p, err = utils.ParsePolicy("type-unsupported!=0")
+	assert.NoError(t, err)
+	assert.True(t, p.Eval(map[string]int64{"type-unsupported": 1}))
+
+	for _, expr := range []string{"", "malicious", "malicious>=x", "bad>1", "malicious>=1 or"} {
+		_, err := utils.ParsePolicy(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestGate(t *testing.T) {
+	p, err := utils.ParsePolicy("malicious>=3")
+	assert.NoError(t, err)
+	out := &bytes.Buffer{}
+	g := utils.NewGate(p)
+	g.Out = out
+
+	object := func(data string) *vt.Object {
+		obj := &vt.Object{}
+		assert.NoError(t, json.Unmarshal([]byte(data), obj))
+		return obj
+	}
+
+	assert.True(t, g.Check("a.exe", object(`{"type": "file", "id": "a", "attributes": {
+		"last_analysis_stats": {"malicious": 5, "suspicious": 1, "undetected": 60}}}`), nil))
+	assert.False(t, g.Check("b.exe", object(`{"type": "file", "id": "b", "attributes": {
+		"last_analysis_stats": {"malicious": 0, "harmless": 2}}}`), nil))
+	assert.False(t, g.Check("c.exe", object(`{"type": "file", "id": "c", "attributes": {}}`), nil))
+	assert.False(t, g.Check("d.exe", nil, errors.New("not found")))
+
+	clean, flagged, failed := g.Counts()
+	assert.Equal(t, 1, clean)
+	assert.Equal(t, 1, flagged)
+	assert.Equal(t, 2, failed)
+	assert.Contains(t, out.String(), "a.exe malicious=5 suspicious=1 harmless=0 undetected=60\n")
+	assert.Contains(t, out.String(), "d.exe: not found\n")
+}
@@ -18,7 +18,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -36,6 +35,10 @@ import (
 
 // Printer prints objects to stdout.
 type Printer struct {
+	// Gate is optional, if not nil the objects retrieved by
+	// GetAndPrintObjects are checked against the gate's policy.
+	Gate *Gate
+
 	client *APIClient
 	colors *yaml.Colors
 	cmd    *cobra.Command
@@ -157,47 +160,103 @@ func (p *Printer) PrintObject(obj *vt.Object) error {
 // them. The endpoint must contain a %s placeholder that will be replaced with
 // items from the args slice. If args contains a single "-" string, the args are
 // read from stdin one per line. If argRe is non-nil, only args that match the
-// regular expression are used and the rest are discarded.
+// regular expression are used and the rest are discarded. If --with-input was
+// used, each object includes an "_input" field with the record from which the
+// arg was read, if r is a RecordReader, or the arg itself if not. Objects that
+// couldn't be retrieved are reported in stderr, and if the failure was other
+// than the object not being found an error is returned after printing the
+// rest, unless a Gate is set, in which case the gate accounts for them.
 func (p *Printer) GetAndPrintObjects(endpoint string, r StringReader, argRe *regexp.Regexp) error {
 	if argRe != nil {
 		r = NewFilteredStringReader(r, argRe)
 	}
 
 	filteredArgs := make([]string, 0)
+	records := make([]interface{}, 0)
 	for s, err := r.ReadString(); s != "" || err == nil; s, err = r.ReadString() {
 		filteredArgs = append(filteredArgs, s)
+		if record := recordOf(r); record != nil {
+			records = append(records, record)
+		} else {
+			records = append(records, s)
+		}
 	}
 
-	objectsCh := make(chan *vt.Object)
-	errorsCh := make(chan error, len(filteredArgs))
+	var objects []*vt.Object
+	var inputs []interface{}
+	var errs []error
 
-	go p.client.RetrieveObjects(endpoint, filteredArgs, objectsCh, errorsCh)
+	p.client.RetrieveObjectsFunc(p.cmd.Context(), endpoint, filteredArgs,
+		func(i int, obj *vt.Object, err error) {
+			if p.Gate != nil {
+				item, ok := records[i].(string)
+				if !ok {
+					item = filteredArgs[i]
+				}
+				p.Gate.Check(item, obj, err)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				objects = append(objects, obj)
+				inputs = append(inputs, records[i])
+			}
+		})
 
 	if viper.GetBool("identifiers-only") {
 		var objectIds []string
-		for obj := range objectsCh {
+		for _, obj := range objects {
 			objectIds = append(objectIds, obj.ID())
 		}
 		if err := p.Print(objectIds); err != nil {
 			return err
 		}
-	} else {
-		var objects []*vt.Object
-		for obj := range objectsCh {
-			objects = append(objects, obj)
+	} else if viper.GetBool("with-input") {
+		if err := p.printObjectsWithInput(objects, inputs); err != nil {
+			return err
 		}
+	} else {
 		if err := p.PrintObjects(objects); err != nil {
 			return err
 		}
 	}
 
-	for err := range errorsCh {
-		fmt.Fprintln(os.Stderr, err)
+	failed := 0
+	for _, err := range errs {
+		Errorf("%v", err)
+		if apiErr, ok := err.(vt.Error); !ok || apiErr.Code != "NotFoundError" {
+			failed++
+		}
+	}
+
+	if failed > 0 && p.Gate == nil {
+		p.cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d objects couldn't be retrieved", failed, len(filteredArgs))
 	}
 
 	return nil
 }
 
+// printObjectsWithInput prints the specified objects, adding to each of them an
+// "_input" field with the corresponding item from inputs.
+func (p *Printer) printObjectsWithInput(objs []*vt.Object, inputs []interface{}) error {
+	list := make([]map[string]interface{}, 0)
+	for i, obj := range objs {
+		m := ObjectToMap(obj)
+		if viper.IsSet("include") || viper.IsSet("exclude") {
+			m = FilterMap(m,
+				viper.GetStringSlice("include"),
+				viper.GetStringSlice("exclude"))
+		}
+		m["_input"] = inputs[i]
+		list = append(list, m)
+	}
+	if len(list) > 0 {
+		return p.Print(list)
+	}
+	return nil
+}
+
 // PrintCollection prints a collection of objects retrieved from the collection
 // specified by the collection URL.
 func (p *Printer) PrintCollection(collection *url.URL) error {
@@ -0,0 +1,176 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	vt "github.com/VirusTotal/vt-go"
+)
+
+const (
+	// privateMaxPayloadSize is the maximum size of the files that can be
+	// uploaded directly to the private/files endpoint, larger files must be
+	// uploaded to the URL returned by private/files/upload_url.
+	privateMaxPayloadSize = 32 * 1024 * 1024
+	// privateMaxFileSize is the maximum size of the files that can be uploaded
+	// for private scanning.
+	privateMaxFileSize = 650 * 1024 * 1024
+)
+
+// PrivateScanner submits files and URLs to VirusTotal's private scanning,
+// where they are analysed without being shared with anyone outside the
+// user's organization. It's the private counterpart of vt.FileScanner and
+// vt.URLScanner, which only support public scanning.
+type PrivateScanner struct {
+	cli *APIClient
+}
+
+// NewPrivateScanner returns a new PrivateScanner.
+func (c *APIClient) NewPrivateScanner() *PrivateScanner {
+	return &PrivateScanner{cli: c}
+}
+
+// ScanFile uploads a file for private scanning and returns the analysis. The
+// upload progress, as a percentage, is sent to the progress channel, which can
+// be nil. params contains additional form fields sent with the file, like
+// "password", "disable_sandbox" or "retention_period_days".
+func (s *PrivateScanner) ScanFile(f *os.File, progress chan<- float32, params map[string]string) (*vt.Object, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > privateMaxFileSize {
+		return nil, fmt.Errorf("file size can't be larger than %d bytes", privateMaxFileSize)
+	}
+	uploadURL := vt.URL("private/files")
+	if info.Size() > privateMaxPayloadSize {
+		var u string
+		if _, err := s.cli.GetData(vt.URL("private/files/upload_url"), &u); err != nil {
+			return nil, err
+		}
+		if uploadURL, err = url.Parse(u); err != nil {
+			return nil, err
+		}
+	}
+
+	// The form is streamed instead of being built in memory, as files can be
+	// large.
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		err := writeFields(w, params)
+		if err == nil {
+			var part io.Writer
+			if part, err = w.CreateFormFile("file", filepath.Base(f.Name())); err == nil {
+				_, err = io.Copy(part, &progressReader{r: f, total: info.Size(), ch: progress})
+			}
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return s.post(uploadURL, pr, w.FormDataContentType())
+}
+
+// ScanURL submits a URL for private scanning and returns the analysis. params
+// contains additional form fields, like "retention_period_days".
+func (s *PrivateScanner) ScanURL(u string, params map[string]string) (*vt.Object, error) {
+	fields := map[string]string{"url": u}
+	for k, v := range params {
+		fields[k] = v
+	}
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if err := writeFields(w, fields); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return s.post(vt.URL("private/urls"), &b, w.FormDataContentType())
+}
+
+// post sends a POST request with the given body to the API and returns the
+// object in the response.
+func (s *PrivateScanner) post(u *url.URL, body io.Reader, contentType string) (*vt.Object, error) {
+	req, err := http.NewRequest("POST", u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Apikey", s.cli.APIKey)
+	req.Header.Set("User-Agent", s.cli.Agent)
+	resp, err := s.cli.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp vt.Response
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %s", u, resp.Status)
+	}
+	if apiResp.Error.Code != "" {
+		return nil, apiResp.Error
+	}
+	obj := &vt.Object{}
+	if err := json.Unmarshal(apiResp.Data, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// writeFields writes the given fields to a multipart form, sorted by name.
+func writeFields(w *multipart.Writer, fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := w.WriteField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progressReader is an io.Reader that sends to a channel the percentage of
+// the total that has been read.
+type progressReader struct {
+	r     io.Reader
+	total int64
+	read  int64
+	ch    chan<- float32
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.ch != nil && p.total > 0 {
+		p.ch <- float32(p.read) / float32(p.total) * 100
+	}
+	return n, err
+}
@@ -0,0 +1,75 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivateScanner(t *testing.T) {
+	client := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Apikey") != "test-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error": {"code": "WrongCredentialsError", "message": "wrong key"}}`)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error": {"code": "BadRequestError", "message": "bad form"}}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v3/private/files":
+			f, h, err := r.FormFile("file")
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(f)
+			assert.Equal(t, "sample.exe", h.Filename)
+			assert.Equal(t, "MZ sample", string(content))
+			assert.Equal(t, "true", r.FormValue("disable_sandbox"))
+			io.WriteString(w, `{"data": {"type": "private_analysis", "id": "file-analysis"}}`)
+		case "/api/v3/private/urls":
+			assert.Equal(t, "http://example.com", r.FormValue("url"))
+			assert.Equal(t, "7", r.FormValue("retention_period_days"))
+			io.WriteString(w, `{"data": {"type": "private_analysis", "id": "url-analysis"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error": {"code": "NotFoundError", "message": "not found"}}`)
+		}
+	})
+	s := client.NewPrivateScanner()
+
+	path := filepath.Join(t.TempDir(), "sample.exe")
+	assert.NoError(t, os.WriteFile(path, []byte("MZ sample"), 0o644))
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	analysis, err := s.ScanFile(f, nil, map[string]string{"disable_sandbox": "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, "file-analysis", analysis.ID())
+
+	analysis, err = s.ScanURL("http://example.com", map[string]string{"retention_period_days": "7"})
+	assert.NoError(t, err)
+	assert.Equal(t, "url-analysis", analysis.ID())
+
+	client.APIKey = "wrong"
+	_, err = s.ScanURL("http://example.com", nil)
+	assert.EqualError(t, err, "wrong key")
+}
@@ -0,0 +1,287 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RecordReader is a StringReader that reads strings from structured records,
+// like CSV rows or JSON objects. Record returns the record from which the last
+// string returned by ReadString was read.
+type RecordReader interface {
+	StringReader
+	Record() interface{}
+}
+
+// recordOf returns the current record of r if it is a RecordReader, or nil if
+// it is not.
+func recordOf(r StringReader) interface{} {
+	if rr, ok := r.(RecordReader); ok {
+		return rr.Record()
+	}
+	return nil
+}
+
+// Record returns the record from which the last string returned by ReadString
+// was read, if the underlying StringReader is a RecordReader.
+func (f *FilteredStringReader) Record() interface{} {
+	return recordOf(f.r)
+}
+
+// Record returns the record from which the last string returned by ReadString
+// was read if the underlying StringReader is a RecordReader, if not, it returns
+// the last string before being transformed by the map function.
+func (m *MappedStringReader) Record() interface{} {
+	if record := recordOf(m.r); record != nil {
+		return record
+	}
+	return m.last
+}
+
+// CSVReader reads a given column from CSV or TSV data. It implements the
+// RecordReader interface, if the data has a header each record is a map where
+// keys are column names, if not records are slices with the row's values.
+type CSVReader struct {
+	r      *csv.Reader
+	header []string
+	column int
+	record interface{}
+}
+
+// NewCSVReader creates a new CSVReader that reads the column specified by
+// field from the CSV data in r, with fields separated by comma. field can be
+// either the column's number, starting at 1, or the column's name. If field is
+// a name or hasHeader is true, the first row is considered a header.
+func NewCSVReader(r io.Reader, comma rune, field string, hasHeader bool) (*CSVReader, error) {
+	if field == "" {
+		return nil, errors.New("a field must be specified for reading CSV data")
+	}
+	cr := &CSVReader{r: csv.NewReader(r)}
+	cr.r.Comma = comma
+	cr.r.FieldsPerRecord = -1
+	cr.r.LazyQuotes = true
+	cr.r.TrimLeadingSpace = true
+
+	n, err := strconv.Atoi(field)
+	if err != nil {
+		hasHeader = true
+	} else if n < 1 {
+		return nil, fmt.Errorf("invalid field number %d, the first field is 1", n)
+	} else {
+		cr.column = n - 1
+	}
+	if hasHeader {
+		if cr.header, err = cr.r.Read(); err == io.EOF {
+			return cr, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range cr.header {
+			cr.header[i] = strings.TrimSpace(cr.header[i])
+		}
+	}
+	if n == 0 {
+		cr.column = -1
+		for i, name := range cr.header {
+			if strings.EqualFold(name, field) {
+				cr.column = i
+				break
+			}
+		}
+		if cr.column == -1 {
+			return nil, fmt.Errorf("field %q not found in CSV header", field)
+		}
+	}
+	return cr, nil
+}
+
+// ReadString returns the value of the selected column in the next row. Rows
+// where the column is missing or empty are skipped. When all rows have been
+// read ReadString returns an io.EOF error.
+func (cr *CSVReader) ReadString() (string, error) {
+	for {
+		row, err := cr.r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				Warnf("ignoring CSV row: %v", err)
+				continue
+			}
+			return "", err
+		}
+		if cr.column >= len(row) {
+			continue
+		}
+		s := strings.TrimSpace(row[cr.column])
+		if s == "" {
+			continue
+		}
+		if cr.header != nil {
+			m := make(map[string]interface{})
+			for i, v := range row {
+				if i < len(cr.header) {
+					m[cr.header[i]] = v
+				} else {
+					m[strconv.Itoa(i+1)] = v
+				}
+			}
+			cr.record = m
+		} else {
+			cr.record = row
+		}
+		return s, nil
+	}
+}
+
+// Record returns the row from which the last string was read.
+func (cr *CSVReader) Record() interface{} {
+	return cr.record
+}
+
+// JSONLinesReader reads a given field from JSON Lines data, where each line
+// contains a JSON object. It implements the RecordReader interface, records
+// are the decoded JSON objects.
+type JSONLinesReader struct {
+	scanner *bufio.Scanner
+	path    []string
+	line    int
+	record  interface{}
+	pending []string
+}
+
+// NewJSONLinesReader creates a new JSONLinesReader that reads the field
+// specified by path from the JSON Lines data in r. The path can contain dots
+// for referring to nested fields, as in "src.ip", and numbers for referring to
+// array items, as in "hashes.0". If the field is an array all its items are
+// returned.
+func NewJSONLinesReader(r io.Reader, path string) (*JSONLinesReader, error) {
+	if path == "" {
+		return nil, errors.New("a field must be specified for reading JSON Lines data")
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &JSONLinesReader{scanner: scanner, path: strings.Split(path, ".")}, nil
+}
+
+// jsonPathValue returns the value found at the given path in v, or nil if the
+// path doesn't exist.
+func jsonPathValue(v interface{}, path []string) interface{} {
+	for _, key := range path {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v = t[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// jsonPathValues returns the string values found at the given path in v. If
+// the value at that path is an array, the string values in the array are
+// returned.
+func jsonPathValues(v interface{}, path []string) []string {
+	v = jsonPathValue(v, path)
+	values := make([]string, 0)
+	items, isArray := v.([]interface{})
+	if !isArray {
+		items = []interface{}{v}
+	}
+	for _, item := range items {
+		switch t := item.(type) {
+		case string:
+			if s := strings.TrimSpace(t); s != "" {
+				values = append(values, s)
+			}
+		case json.Number:
+			values = append(values, t.String())
+		}
+	}
+	return values
+}
+
+// ReadString returns the next value of the selected field. Lines where the
+// field is missing are skipped, lines that are not valid JSON are skipped with
+// a warning. When all lines have been read ReadString returns an io.EOF error.
+func (jr *JSONLinesReader) ReadString() (string, error) {
+	for len(jr.pending) == 0 {
+		if !jr.scanner.Scan() {
+			if err := jr.scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		jr.line++
+		line := bytes.TrimSpace(jr.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record interface{}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&record); err != nil {
+			Warnf("ignoring line %d: %v", jr.line, err)
+			continue
+		}
+		jr.record = record
+		jr.pending = jsonPathValues(record, jr.path)
+	}
+	s := jr.pending[0]
+	jr.pending = jr.pending[1:]
+	return s, nil
+}
+
+// Record returns the JSON object from which the last string was read.
+func (jr *JSONLinesReader) Record() interface{} {
+	return jr.record
+}
+
+// RecordArrayReader is like StringArrayReader, but each string is associated
+// to a record. It implements the RecordReader interface.
+type RecordArrayReader struct {
+	StringArrayReader
+	records []interface{}
+}
+
+// NewRecordArrayReader creates a new RecordArrayReader, records must have the
+// same length than strings.
+func NewRecordArrayReader(strings []string, records []interface{}) *RecordArrayReader {
+	return &RecordArrayReader{
+		StringArrayReader: StringArrayReader{strings: strings},
+		records:           records,
+	}
+}
+
+// Record returns the record associated to the last string returned.
+func (rr *RecordArrayReader) Record() interface{} {
+	if rr.pos == 0 {
+		return nil
+	}
+	return rr.records[rr.pos-1]
+}
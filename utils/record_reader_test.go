@@ -0,0 +1,125 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll returns the strings and records read from r.
+func readAll(r utils.RecordReader) ([]string, []interface{}) {
+	var values []string
+	var records []interface{}
+	for s, err := r.ReadString(); err == nil; s, err = r.ReadString() {
+		values = append(values, s)
+		records = append(records, r.Record())
+	}
+	return values, records
+}
+
+const csvData = `host, sha256, action
+ws1,44d88612fea8a8f36de82e1278abb02f,allow
+ws2,,deny
+ws3,"3395856ce81f2b7382dee72602f798b642f14140",deny
+`
+
+func TestCSVReader(t *testing.T) {
+	r, err := utils.NewCSVReader(strings.NewReader(csvData), ',', "SHA256", false)
+	assert.NoError(t, err)
+	values, records := readAll(r)
+	assert.Equal(t, []string{
+		"44d88612fea8a8f36de82e1278abb02f",
+		"3395856ce81f2b7382dee72602f798b642f14140"}, values)
+	assert.Equal(t, map[string]interface{}{
+		"host":   "ws1",
+		"sha256": "44d88612fea8a8f36de82e1278abb02f",
+		"action": "allow"}, records[0])
+
+	r, err = utils.NewCSVReader(strings.NewReader(csvData), ',', "1", false)
+	assert.NoError(t, err)
+	values, records = readAll(r)
+	assert.Equal(t, []string{"host", "ws1", "ws2", "ws3"}, values)
+	assert.Equal(t, []string{"ws2", "", "deny"}, records[2])
+
+	r, err = utils.NewCSVReader(strings.NewReader(csvData), ',', "1", true)
+	assert.NoError(t, err)
+	values, _ = readAll(r)
+	assert.Equal(t, []string{"ws1", "ws2", "ws3"}, values)
+
+	r, err = utils.NewCSVReader(strings.NewReader("a\tb\n1\t2\n"), '\t', "b", false)
+	assert.NoError(t, err)
+	values, _ = readAll(r)
+	assert.Equal(t, []string{"2"}, values)
+
+	_, err = utils.NewCSVReader(strings.NewReader(csvData), ',', "md5", false)
+	assert.Error(t, err)
+	_, err = utils.NewCSVReader(strings.NewReader(csvData), ',', "0", false)
+	assert.Error(t, err)
+}
+
+const jsonlData = `{"src": {"ip": "8.8.8.8", "port": 53}}
+not json
+
+{"src": {"ip": ["1.1.1.1", "9.9.9.9"]}}
+{"dst": {"ip": "8.8.4.4"}}
+{"src": {"port": 80}}
+`
+
+func TestJSONLinesReader(t *testing.T) {
+	r, err := utils.NewJSONLinesReader(strings.NewReader(jsonlData), "src.ip")
+	assert.NoError(t, err)
+	values, records := readAll(r)
+	assert.Equal(t, []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}, values)
+	assert.Equal(t, map[string]interface{}{
+		"src": map[string]interface{}{
+			"ip":   "8.8.8.8",
+			"port": json.Number("53")}}, records[0])
+	assert.Equal(t, records[1], records[2])
+
+	r, err = utils.NewJSONLinesReader(strings.NewReader(jsonlData), "src.ip.1")
+	assert.NoError(t, err)
+	values, _ = readAll(r)
+	assert.Equal(t, []string{"9.9.9.9"}, values)
+
+	r, err = utils.NewJSONLinesReader(strings.NewReader(jsonlData), "src.port")
+	assert.NoError(t, err)
+	values, _ = readAll(r)
+	assert.Equal(t, []string{"53", "80"}, values)
+}
+
+func TestRecordsThroughReaders(t *testing.T) {
+	r, err := utils.NewCSVReader(strings.NewReader(csvData), ',', "sha256", false)
+	assert.NoError(t, err)
+	f := utils.NewFilteredStringReader(r, regexp.MustCompile("^[[:xdigit:]]{40}$"))
+	values, records := readAll(f)
+	assert.Equal(t, []string{"3395856ce81f2b7382dee72602f798b642f14140"}, values)
+	assert.Equal(t, "ws3", records[0].(map[string]interface{})["host"])
+
+	m := utils.NewMappedStringReader(
+		utils.NewStringArrayReader([]string{"a", "b"}), strings.ToUpper)
+	values, records = readAll(m)
+	assert.Equal(t, []string{"A", "B"}, values)
+	assert.Equal(t, []interface{}{"a", "b"}, records)
+
+	ir := utils.NewIPRangeReader(utils.NewStringArrayReader([]string{"192.0.2.0/31"}), 4)
+	values, records = readAll(ir)
+	assert.Equal(t, []string{"192.0.2.0", "192.0.2.1"}, values)
+	assert.Equal(t, []interface{}{"192.0.2.0/31", "192.0.2.0/31"}, records)
+}
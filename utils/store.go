@@ -0,0 +1,170 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Formats in which downloaded samples can be stored.
+const (
+	// StorePlain stores samples as they are.
+	StorePlain = "plain"
+	// StoreGzip stores samples compressed with gzip, with a .gz extension.
+	StoreGzip = "gzip"
+	// StoreEncryptedZip stores each sample in a ZIP archive encrypted with
+	// a password, with a .zip extension.
+	StoreEncryptedZip = "encrypted-zip"
+	// StoreNeutered stores samples as they are, but with a .vir extension,
+	// so that they are not run or opened by accident.
+	StoreNeutered = "neutered"
+)
+
+// DefaultStorePassword is the password of the ZIP archives written with
+// StoreEncryptedZip, which is the one traditionally used for sharing malware.
+const DefaultStorePassword = "infected"
+
+var storeExtensions = map[string]string{
+	StorePlain:        "",
+	StoreGzip:         ".gz",
+	StoreEncryptedZip: ".zip",
+	StoreNeutered:     ".vir",
+}
+
+// SampleStore stores downloaded samples in one of the supported formats.
+type SampleStore struct {
+	Format string
+	// Password is the password used for StoreEncryptedZip.
+	Password string
+}
+
+// NewSampleStore returns a SampleStore for the given format. If format is
+// empty StorePlain is used.
+func NewSampleStore(format string) (*SampleStore, error) {
+	if format == "" {
+		format = StorePlain
+	}
+	if _, ok := storeExtensions[format]; !ok {
+		return nil, fmt.Errorf("invalid store format %q, use %s, %s, %s or %s", format,
+			StorePlain, StoreGzip, StoreEncryptedZip, StoreNeutered)
+	}
+	return &SampleStore{Format: format, Password: DefaultStorePassword}, nil
+}
+
+// Path returns the path where a sample that would be stored at path as is is
+// actually stored, which includes the extension corresponding to the format.
+func (s *SampleStore) Path(path string) string {
+	return path + storeExtensions[s.Format]
+}
+
+// Store stores the sample in the file src at dst, which must be a path
+// returned by Path. The sample is written to a temporary file that is renamed
+// to dst once it's complete, so that dst never contains a partial sample.
+// src is removed once the sample is stored.
+func (s *SampleStore) Store(src, dst string) error {
+	if s.Format == StorePlain || s.Format == StoreNeutered {
+		return os.Rename(src, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	// The name of the sample inside the archive is the name of dst without
+	// the extension added by Path.
+	name := strings.TrimSuffix(filepath.Base(dst), storeExtensions[s.Format])
+	switch s.Format {
+	case StoreGzip:
+		gw := gzip.NewWriter(out)
+		gw.Name = name
+		if _, err = io.Copy(gw, in); err == nil {
+			err = gw.Close()
+		}
+	case StoreEncryptedZip:
+		err = WriteEncryptedZip(out, name, in, s.Password)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(out.Name(), dst); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// Open opens a sample stored at path, which must be a path returned by Path,
+// and returns a reader for its original content.
+func (s *SampleStore) Open(path string) (io.ReadCloser, error) {
+	switch s.Format {
+	case StoreGzip:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &multiCloser{Reader: gr, closers: []io.Closer{gr, f}}, nil
+	case StoreEncryptedZip:
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(zr.File) != 1 {
+			zr.Close()
+			return nil, fmt.Errorf("%s: expecting a single file in the archive", path)
+		}
+		rc, err := openZipFile(zr.File[0], s.Password)
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+		return &multiCloser{Reader: rc, closers: []io.Closer{rc, zr}}, nil
+	}
+	return os.Open(path)
+}
+
+// multiCloser is an io.ReadCloser that closes multiple io.Closer.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleStore(t *testing.T) {
+	content := strings.Repeat("MZ sample content\n", 1000)
+	for format, ext := range map[string]string{
+		utils.StorePlain:        "",
+		utils.StoreGzip:         ".gz",
+		utils.StoreEncryptedZip: ".zip",
+		utils.StoreNeutered:     ".vir",
+	} {
+		dir := t.TempDir()
+		src := filepath.Join(dir, ".sample.part")
+		assert.NoError(t, os.WriteFile(src, []byte(content), 0o644))
+
+		s, err := utils.NewSampleStore(format)
+		assert.NoError(t, err)
+		dst := s.Path(filepath.Join(dir, "sample.exe"))
+		assert.Equal(t, filepath.Join(dir, "sample.exe"+ext), dst)
+		assert.NoError(t, s.Store(src, dst), format)
+
+		_, err = os.Stat(src)
+		assert.True(t, os.IsNotExist(err), format)
+		entries, _ := os.ReadDir(dir)
+		assert.Len(t, entries, 1, format)
+
+		r, err := s.Open(dst)
+		assert.NoError(t, err, format)
+		b, err := io.ReadAll(r)
+		assert.NoError(t, err, format)
+		assert.NoError(t, r.Close())
+		assert.Equal(t, content, string(b), format)
+	}
+
+	_, err := utils.NewSampleStore("rar")
+	assert.Error(t, err)
+}
+
+func TestWriteEncryptedZip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sample.zip")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+	assert.NoError(t, utils.WriteEncryptedZip(f, "sample.exe", strings.NewReader("MZ sample"), "infected"))
+	assert.NoError(t, f.Close())
+
+	var members []string
+	assert.NoError(t, utils.WalkArchive(filename, "infected", func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "MZ sample", string(b))
+		members = append(members, name)
+		return nil
+	}))
+	assert.Equal(t, []string{utils.ArchiveMemberPath(filename, "sample.exe")}, members)
+
+	s := &utils.SampleStore{Format: utils.StoreEncryptedZip, Password: "wrong"}
+	_, err = s.Open(filename)
+	assert.ErrorIs(t, err, utils.ErrWrongPassword)
+}
@@ -59,7 +59,11 @@ type StringIOReader struct {
 
 // NewStringIOReader creates a new StringIOReader.
 func NewStringIOReader(r io.Reader) *StringIOReader {
-	return &StringIOReader{scanner: bufio.NewScanner(r)}
+	scanner := bufio.NewScanner(r)
+	// Allow lines longer than the default limit of 64KB, which are common in
+	// log files.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &StringIOReader{scanner: scanner}
 }
 
 // ReadString reads one string from StringIOReader. When all strings have
@@ -104,6 +108,7 @@ func (f *FilteredStringReader) ReadString() (s string, err error) {
 type MappedStringReader struct {
 	r     StringReader
 	mapFn func(string) string
+	last  string
 }
 
 // NewMappedStringReader creates a new MappedStringReader that reads strings from
@@ -117,6 +122,7 @@ func NewMappedStringReader(r StringReader, mapFn func(string) string) *MappedStr
 // returns the result produced by the map function.
 func (m *MappedStringReader) ReadString() (s string, err error) {
 	if s, err = m.r.ReadString(); err == nil {
+		m.last = s
 		return m.mapFn(s), nil
 	}
 	return s, err
@@ -132,3 +138,17 @@ func StringReaderFromCmdArgs(args []string) StringReader {
 		return NewStringArrayReader(args)
 	}
 }
+
+// inMemory returns true if all the strings returned by r are in memory, and
+// therefore reading them never blocks.
+func inMemory(r StringReader) bool {
+	switch v := r.(type) {
+	case *StringArrayReader:
+		return true
+	case *FilteredStringReader:
+		return inMemory(v.r)
+	case *MappedStringReader:
+		return inMemory(v.r)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// maxTracedErrorBody is the maximum number of bytes of an error response's
+// body that are included in the trace.
+const maxTracedErrorBody = 4096
+
+// TracingTransport is a http.RoundTripper that logs every request and its
+// response with level LogDebug. For each request it logs the method, URL,
+// status code, latency and response size. For error responses (status codes
+// 4xx and 5xx) the response body is logged too. Headers containing the API key
+// or credentials are never logged.
+type TracingTransport struct {
+	Transport http.RoundTripper
+	requests  int64
+}
+
+// NewTracingTransport returns a TracingTransport that wraps t.
+func NewTracingTransport(t http.RoundTripper) *TracingTransport {
+	return &TracingTransport{Transport: t}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := atomic.AddInt64(&t.requests, 1)
+	start := time.Now()
+
+	Debugf("> [%d] %s %s", id, req.Method, req.URL)
+	for name, values := range req.Header {
+		if strings.EqualFold(name, "x-apikey") || strings.EqualFold(name, "authorization") ||
+			strings.EqualFold(name, "proxy-authorization") {
+			values = []string{"<redacted>"}
+		}
+		Debugf("> [%d] %s: %s", id, name, strings.Join(values, ", "))
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		Debugf("< [%d] %s %s failed after %v: %v", id, req.Method, req.URL, latency, err)
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body = traceErrorBody(id, resp)
+	}
+
+	resp.Body = &tracedBody{
+		ReadCloser: resp.Body,
+		onClose: func(n int64) {
+			Debugf("< [%d] %s %s %s in %v, %s",
+				id, req.Method, req.URL, resp.Status,
+				latency.Round(time.Millisecond), humanize.Bytes(uint64(n)))
+		},
+	}
+
+	return resp, nil
+}
+
+// traceErrorBody reads the body of an error response, logs it and returns a
+// new body with the same content, so that it can be read again by the caller.
+func traceErrorBody(id int64, resp *http.Response) io.ReadCloser {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		Debugf("< [%d] error reading response body: %v", id, err)
+	}
+	text := body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		if r, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if b, err := io.ReadAll(io.LimitReader(r, maxTracedErrorBody)); err == nil {
+				text = b
+			}
+		}
+	}
+	if len(text) > maxTracedErrorBody {
+		text = text[:maxTracedErrorBody]
+	}
+	Debugf("< [%d] %s", id, strings.TrimSpace(string(text)))
+	return io.NopCloser(bytes.NewReader(body))
+}
+
+// tracedBody wraps a response body, counting the number of bytes read from it
+// and calling onClose with that number when the body is closed.
+type tracedBody struct {
+	io.ReadCloser
+	n       int64
+	closed  int32
+	onClose func(int64)
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.n, int64(n))
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if atomic.CompareAndSwapInt32(&b.closed, 0, 1) {
+		b.onClose(atomic.LoadInt64(&b.n))
+	}
+	return err
+}
@@ -0,0 +1,312 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewHTTPClient returns the http.Client used for all the requests sent by the
+// program, both API calls and file downloads. The client is configured with
+// the --proxy, --proxy-user, --no-proxy, --timeout, --ca-file, --client-cert
+// and --client-key settings, which can be provided as command-line flags,
+// environment variables or in the config file. Requests and responses are
+// traced when using --trace or --log-file.
+func NewHTTPClient() (*http.Client, error) {
+	proxy, err := ProxyFunc(
+		viper.GetString("proxy"),
+		viper.GetString("proxy-user"),
+		viper.GetString("no-proxy"))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := TLSConfig(
+		viper.GetString("ca-file"),
+		viper.GetString("client-cert"),
+		viper.GetString("client-key"))
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := viper.GetDuration("timeout")
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if timeout == 0 {
+		transport.TLSHandshakeTimeout = 10 * time.Second
+	}
+
+	// Trace requests if --trace was used or if there's a log file, in the
+	// latter case the traces go only to the log file.
+	if viper.GetBool("trace") || LogFileEnabled() {
+		return &http.Client{Transport: NewTracingTransport(transport)}, nil
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// ProxyFunc returns a function suitable for http.Transport.Proxy. proxyURL can
+// use the http, https, socks5 or socks5h schemes, when no scheme is specified
+// http is assumed. Credentials for the proxy can be included in the URL itself
+// or provided in proxyUser with the "user:password" format. noProxy is a comma
+// separated list of host names, domain suffixes, IP addresses or CIDR blocks
+// that must be accessed directly, following the same conventions as the
+// NO_PROXY environment variable. If proxyURL is empty the proxy is taken from
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, and
+// proxyUser and noProxy are applied on top of it.
+func ProxyFunc(proxyURL, proxyUser, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return environmentProxyFunc(proxyUser, noProxy), nil
+	}
+
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+
+	setProxyUser(u, proxyUser)
+
+	if noProxy == "" {
+		noProxy = os.Getenv("NO_PROXY")
+	}
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+
+	bypass := parseNoProxy(noProxy)
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL.Hostname()) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// environmentProxyFunc returns a function like http.ProxyFromEnvironment,
+// which additionally sets the credentials in proxyUser, if any, and bypasses
+// the proxy for the hosts in noProxy.
+func environmentProxyFunc(proxyUser, noProxy string) func(*http.Request) (*url.URL, error) {
+	if proxyUser == "" && noProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	bypass := parseNoProxy(noProxy)
+	envProxy := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL.Hostname()) {
+			return nil, nil
+		}
+		u, err := envProxy(req.URL)
+		if u == nil || err != nil {
+			return u, err
+		}
+		// The URL returned is shared by all the requests.
+		proxy := *u
+		setProxyUser(&proxy, proxyUser)
+		return &proxy, nil
+	}
+}
+
+// setProxyUser sets the credentials in proxyUser, with the "user:password"
+// format, in the proxy URL u. If proxyUser is empty u is not modified.
+func setProxyUser(u *url.URL, proxyUser string) {
+	if proxyUser == "" {
+		return
+	}
+	if user, password, ok := strings.Cut(proxyUser, ":"); ok {
+		u.User = url.UserPassword(user, password)
+	} else {
+		u.User = url.User(user)
+	}
+}
+
+// TLSConfig returns the TLS configuration used when connecting to the API.
+// If caFile is not empty the certificates in that PEM file are trusted in
+// addition to the system's root certificates, which is required when the
+// traffic goes through a TLS-inspecting gateway. If clientCert is not empty
+// the certificate is presented to the server, clientKey is the file with the
+// certificate's private key, when empty the key is expected to be in the same
+// file as the certificate.
+func TLSConfig(caFile, clientCert, clientKey string) (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if caFile != "" {
+		pool, _, err := LoadCAFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if clientCert != "" {
+		if clientKey == "" {
+			clientKey = clientCert
+		}
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	} else if clientKey != "" {
+		return nil, fmt.Errorf("--client-key must be used with --client-cert")
+	}
+
+	return config, nil
+}
+
+// LoadCAFile returns a certificate pool with the system's root certificates
+// plus the ones in the given PEM file. It also returns the number of
+// certificates read from the file.
+func LoadCAFile(caFile string) (*x509.CertPool, int, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading CA file: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	n := 0
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error parsing CA file: %v", err)
+		}
+		pool.AddCert(cert)
+		n++
+	}
+	if n == 0 {
+		return nil, 0, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+	return pool, n, nil
+}
+
+// RedactedProxy returns the proxy URL with the password, if any, replaced
+// by "xxxxx". Used for displaying the proxy in verbose mode.
+func RedactedProxy(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.User == nil {
+		return proxyURL
+	}
+	return u.Redacted()
+}
+
+// noProxyList contains the rules parsed from a NO_PROXY-like string.
+type noProxyList struct {
+	all      bool
+	networks []*net.IPNet
+	ips      []net.IP
+	domains  []string
+}
+
+func parseNoProxy(s string) *noProxyList {
+	l := &noProxyList{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			l.all = true
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			l.networks = append(l.networks, network)
+			continue
+		}
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+		if ip := net.ParseIP(strings.Trim(entry, "[]")); ip != nil {
+			l.ips = append(l.ips, ip)
+			continue
+		}
+		l.domains = append(l.domains, strings.TrimPrefix(entry, "*"))
+	}
+	return l
+}
+
+// matches returns true if the given host must not go through the proxy.
+func (l *noProxyList) matches(host string) bool {
+	if l.all {
+		return true
+	}
+	host = strings.ToLower(host)
+	if ip := net.ParseIP(host); ip != nil {
+		for _, i := range l.ips {
+			if i.Equal(ip) {
+				return true
+			}
+		}
+		for _, n := range l.networks {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range l.domains {
+		// Entries starting with a dot match subdomains only, the rest
+		// match both the domain itself and its subdomains.
+		if strings.HasPrefix(d, ".") {
+			if strings.HasSuffix(host, d) {
+				return true
+			}
+		} else if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+	glob "github.com/gobwas/glob"
+)
+
+// Walker finds the files inside a set of files and directories.
+type Walker struct {
+	// Recursive indicates whether subdirectories are walked too. If false only
+	// the files directly inside the given directories are found.
+	Recursive bool
+	// Include contains glob patterns, like "*.exe", for the files that must be
+	// included. If empty, all files are included. Patterns without a slash are
+	// matched against the file name, the rest against the path relative to the
+	// directory being walked.
+	Include []string
+	// Exclude contains glob patterns for the files and directories that must
+	// be excluded, with the same syntax than Include.
+	Exclude []string
+	// MaxSize is the maximum size of the included files, in bytes. Zero means
+	// no limit.
+	MaxSize int64
+	// FollowSymlinks indicates whether symbolic links found while walking a
+	// directory are followed. Symbolic links passed directly to Walk are
+	// always followed.
+	FollowSymlinks bool
+
+	include []glob.Glob
+	exclude []glob.Glob
+	visited map[string]bool
+}
+
+// ParseSize parses a size like "10MB", "512k" or "1048576" and returns the
+// number of bytes. An empty string is zero.
+func ParseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n), nil
+}
+
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, p := range patterns {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+// matchGlobs returns true if the file with the given name and relative path
+// matches any of the globs. The patterns are the ones from which globs were
+// compiled.
+func matchGlobs(globs []glob.Glob, patterns []string, name, rel string) bool {
+	for i, g := range globs {
+		if strings.Contains(patterns[i], "/") {
+			if g.Match(filepath.ToSlash(rel)) {
+				return true
+			}
+		} else if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn for each file found in paths. Directories are walked, files
+// are passed to fn as they are. Include and Exclude apply
+// only to the files found inside directories, while MaxSize applies to all of
+// them. Files that can't be accessed are reported with a warning and skipped.
+// If fn returns an error the walk stops and Walk returns that error, except
+// for fs.SkipAll, which stops the walk without errors.
+func (w *Walker) Walk(paths []string, fn func(path string, info fs.FileInfo) error) error {
+	var err error
+	if w.include, err = compileGlobs(w.Include); err != nil {
+		return err
+	}
+	if w.exclude, err = compileGlobs(w.Exclude); err != nil {
+		return err
+	}
+	w.visited = make(map[string]bool)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			Warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if info.IsDir() {
+			err = w.walkDir(path, path, fn)
+		} else {
+			err = w.visitFile(path, info, fn)
+		}
+		if errors.Is(err, fs.SkipAll) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Walker) visitFile(path string, info fs.FileInfo, fn func(string, fs.FileInfo) error) error {
+	if !info.Mode().IsRegular() {
+		Debugf("skipping %s: not a regular file", path)
+		return nil
+	}
+	if w.MaxSize > 0 && info.Size() > w.MaxSize {
+		Debugf("skipping %s: larger than %d bytes", path, w.MaxSize)
+		return nil
+	}
+	return fn(path, info)
+}
+
+func (w *Walker) walkDir(root, dir string, fn func(string, fs.FileInfo) error) error {
+	// Keep track of the directories already walked, so that symbolic links
+	// pointing to a parent directory don't cause infinite loops.
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if w.visited[real] {
+			return nil
+		}
+		w.visited[real] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		Warnf("skipping %s: %v", dir, err)
+		return nil
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		rel, _ := filepath.Rel(root, path)
+		if matchGlobs(w.exclude, w.Exclude, entry.Name(), rel) {
+			continue
+		}
+		info, err := entry.Info()
+		if err == nil && entry.Type()&fs.ModeSymlink != 0 {
+			if !w.FollowSymlinks {
+				Debugf("skipping symbolic link %s", path)
+				continue
+			}
+			info, err = os.Stat(path)
+		}
+		if err != nil {
+			Warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if info.IsDir() {
+			if !w.Recursive {
+				Debugf("skipping directory %s", path)
+				continue
+			}
+			err = w.walkDir(root, path, fn)
+		} else if len(w.include) == 0 || matchGlobs(w.include, w.Include, entry.Name(), rel) {
+			err = w.visitFile(path, info, fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileSet is a set of files found by Walker.Collect.
+type FileSet struct {
+	Paths []string
+	// Size is the total size of the files in Paths.
+	Size int64
+	// Duplicates is the number of files left out because they have the same
+	// content as some file in Paths.
+	Duplicates int
+}
+
+// Collect returns the files found in paths, see Walk. The same file is never
+// included twice, even if it's found through different paths. If dedupe is
+// true the files are hashed and only the first file found with each content is
+// included.
+func (w *Walker) Collect(paths []string, dedupe bool) (*FileSet, error) {
+	set := &FileSet{Paths: make([]string, 0)}
+	seenPaths := make(map[string]bool)
+	seenHashes := make(map[string]bool)
+	err := w.Walk(paths, func(path string, info fs.FileInfo) error {
+		if abs, err := filepath.Abs(path); err == nil {
+			if real, err := filepath.EvalSymlinks(abs); err == nil {
+				abs = real
+			}
+			if seenPaths[abs] {
+				return nil
+			}
+			seenPaths[abs] = true
+		}
+		if dedupe {
+			h, err := HashFile(path)
+			if err != nil {
+				Warnf("skipping %s: %v", path, err)
+				return nil
+			}
+			if seenHashes[h.SHA256] {
+				Debugf("skipping %s: duplicate content", path)
+				set.Duplicates++
+				return nil
+			}
+			seenHashes[h.SHA256] = true
+		}
+		set.Paths = append(set.Paths, path)
+		set.Size += info.Size()
+		return nil
+	})
+	return set, err
+}
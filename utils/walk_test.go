@@ -0,0 +1,155 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func walk(t *testing.T, w *utils.Walker, root string, paths ...string) []string {
+	files := make([]string, 0)
+	err := w.Walk(paths, func(path string, info fs.FileInfo) error {
+		rel, _ := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	assert.NoError(t, err)
+	sort.Strings(files)
+	return files
+}
+
+func TestWalker(t *testing.T) {
+	root := t.TempDir()
+	for name, size := range map[string]int{
+		"a.exe":            10,
+		"b.txt":            10,
+		"big.exe":          2000,
+		"sub/c.exe":        10,
+		"sub/deep/d.dll":   10,
+		".git/objects/e":   10,
+		"other/sub/f.exe":  10,
+		"other/g.exe.part": 10,
+	} {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+	}
+	assert.NoError(t, os.Symlink(filepath.Join(root, "other"), filepath.Join(root, "sub", "link")))
+	assert.NoError(t, os.Symlink(root, filepath.Join(root, "sub", "loop")))
+
+	assert.Equal(t, []string{
+		".git/objects/e", "a.exe", "b.txt", "big.exe", "other/g.exe.part",
+		"other/sub/f.exe", "sub/c.exe", "sub/deep/d.dll",
+	}, walk(t, &utils.Walker{Recursive: true}, root, root))
+
+	assert.Equal(t, []string{"a.exe", "other/sub/f.exe", "sub/c.exe", "sub/deep/d.dll"},
+		walk(t, &utils.Walker{
+			Recursive: true,
+			Include:   []string{"*.exe", "*.dll"},
+			Exclude:   []string{".git"},
+			MaxSize:   1024,
+		}, root, root))
+
+	// Patterns with a slash are matched against the relative path.
+	assert.Equal(t, []string{"sub/c.exe"},
+		walk(t, &utils.Walker{Recursive: true, Include: []string{"sub/*.exe"}}, root, root))
+
+	// Symbolic links are followed only when requested, and loops are detected.
+	// Through sub/loop only the files in root are found, because sub and other
+	// were walked already.
+	assert.Equal(t, []string{
+		"sub/c.exe", "sub/deep/d.dll", "sub/link/g.exe.part", "sub/link/sub/f.exe",
+		"sub/loop/.git/objects/e", "sub/loop/a.exe", "sub/loop/b.txt", "sub/loop/big.exe",
+	}, walk(t, &utils.Walker{Recursive: true, FollowSymlinks: true}, root, filepath.Join(root, "sub"), filepath.Join(root, "other")))
+	assert.Equal(t, []string{"sub/c.exe", "sub/deep/d.dll", "sub/link/g.exe.part", "sub/link/sub/f.exe"},
+		walk(t, &utils.Walker{Recursive: true, FollowSymlinks: true, Exclude: []string{"loop"}}, root, filepath.Join(root, "sub")))
+
+	// Files passed directly are included even if they don't match the patterns.
+	assert.Equal(t, []string{"b.txt"},
+		walk(t, &utils.Walker{Recursive: true, Include: []string{"*.exe"}}, root, filepath.Join(root, "b.txt")))
+
+	assert.Error(t, (&utils.Walker{Recursive: true, Include: []string{"[a"}}).Walk([]string{root}, nil))
+
+	// Without Recursive only the files directly inside the directories are
+	// found.
+	assert.Equal(t, []string{"a.exe", "b.txt", "big.exe", "sub/c.exe"},
+		walk(t, &utils.Walker{}, root, root, filepath.Join(root, "sub")))
+}
+
+func TestWalkerCollect(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"a.exe":     "same",
+		"b.exe":     "same",
+		"sub/c.exe": "different",
+	} {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	w := &utils.Walker{Recursive: true}
+
+	// The same file is included once even if it's found twice.
+	set, err := w.Collect([]string{root, filepath.Join(root, "a.exe")}, false)
+	assert.NoError(t, err)
+	assert.Len(t, set.Paths, 3)
+	assert.Equal(t, int64(17), set.Size)
+	assert.Equal(t, 0, set.Duplicates)
+
+	set, err = w.Collect([]string{root}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a.exe"), filepath.Join(root, "sub", "c.exe")}, set.Paths)
+	assert.Equal(t, int64(13), set.Size)
+	assert.Equal(t, 1, set.Duplicates)
+}
+
+func TestParseSize(t *testing.T) {
+	n, err := utils.ParseSize("32MB")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(32000000), n)
+	n, err = utils.ParseSize("1KiB")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), n)
+	n, err = utils.ParseSize("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	_, err = utils.ParseSize("big")
+	assert.Error(t, err)
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello")
+	assert.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+	h, err := utils.HashFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &utils.FileHashes{
+		MD5:    "b1946ac92492d2347c6235b4d2611184",
+		SHA1:   "f572d396fae9206628714fb2ce00f72e94f2258f",
+		SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		Size:   6,
+	}, h)
+
+	assert.True(t, h.Matches("B1946AC92492D2347C6235B4D2611184"))
+	assert.True(t, h.Matches("f572d396fae9206628714fb2ce00f72e94f2258f"))
+	assert.True(t, h.Matches("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"))
+	assert.False(t, h.Matches("44d88612fea8a8f36de82e1278abb02f"))
+	assert.False(t, h.Matches("hello"))
+}
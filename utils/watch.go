@@ -0,0 +1,111 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// watchedFile is the state of a file seen by a DirWatcher.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	// since is the time when the file's size and modification time were
+	// first seen with their current values.
+	since time.Time
+	// ready is true if the file was already returned as ready.
+	ready bool
+}
+
+// DirWatcher watches a directory for new or modified files, by walking it
+// periodically.
+type DirWatcher struct {
+	// Walker is used for walking the directory, it determines which files are
+	// watched.
+	Walker *Walker
+	// Interval is the time between two walks of the directory.
+	Interval time.Duration
+	// Settle is the time that a file must remain unchanged before it is
+	// considered ready, so that files are not processed while they are
+	// still being written.
+	Settle time.Duration
+
+	dir   string
+	files map[string]*watchedFile
+}
+
+// NewDirWatcher creates a new DirWatcher for the given directory.
+func NewDirWatcher(dir string, walker *Walker, interval, settle time.Duration) *DirWatcher {
+	return &DirWatcher{
+		Walker:   walker,
+		Interval: interval,
+		Settle:   settle,
+		dir:      dir,
+		files:    make(map[string]*watchedFile),
+	}
+}
+
+// Poll walks the directory and returns the files that are ready, which are
+// the new or modified files that didn't change during the settle time. Each
+// file is returned once, unless it is modified again.
+func (w *DirWatcher) Poll(now time.Time) ([]string, error) {
+	ready := make([]string, 0)
+	seen := make(map[string]bool)
+	err := w.Walker.Walk([]string{w.dir}, func(path string, info fs.FileInfo) error {
+		seen[path] = true
+		f, ok := w.files[path]
+		if !ok || f.size != info.Size() || !f.modTime.Equal(info.ModTime()) {
+			w.files[path] = &watchedFile{size: info.Size(), modTime: info.ModTime(), since: now}
+		} else if !f.ready && now.Sub(f.since) >= w.Settle {
+			f.ready = true
+			ready = append(ready, path)
+		}
+		return nil
+	})
+	// Forget the files that don't exist anymore.
+	for path := range w.files {
+		if !seen[path] {
+			delete(w.files, path)
+		}
+	}
+	return ready, err
+}
+
+// Watch polls the directory every Interval and sends the files that are ready
+// to ch. It returns when ctx starts draining (see Draining).
+func (w *DirWatcher) Watch(ctx context.Context, ch chan<- interface{}) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	draining := Draining(ctx)
+	for {
+		ready, err := w.Poll(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, path := range ready {
+			select {
+			case ch <- path:
+			case <-draining:
+				return nil
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-draining:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright © 2026 The VirusTotal CLI authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VirusTotal/vt-cli/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirWatcher(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.exe")
+	b := filepath.Join(dir, "done", "b.exe")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(b), 0o755))
+	assert.NoError(t, os.WriteFile(a, []byte("a"), 0o644))
+	assert.NoError(t, os.WriteFile(b, []byte("b"), 0o644))
+
+	w := utils.NewDirWatcher(dir, &utils.Walker{Recursive: true, Exclude: []string{"done/**"}},
+		time.Second, 10*time.Second)
+	now := time.Now()
+
+	ready, err := w.Poll(now)
+	assert.NoError(t, err)
+	assert.Empty(t, ready)
+
+	// The file is ready once it didn't change during the settle time.
+	ready, _ = w.Poll(now.Add(5 * time.Second))
+	assert.Empty(t, ready)
+	ready, _ = w.Poll(now.Add(10 * time.Second))
+	assert.Equal(t, []string{a}, ready)
+	ready, _ = w.Poll(now.Add(20 * time.Second))
+	assert.Empty(t, ready)
+
+	// Modified files are returned again after settling.
+	assert.NoError(t, os.WriteFile(a, []byte("modified"), 0o644))
+	ready, _ = w.Poll(now.Add(21 * time.Second))
+	assert.Empty(t, ready)
+	ready, _ = w.Poll(now.Add(31 * time.Second))
+	assert.Equal(t, []string{a}, ready)
+}
@@ -14,10 +14,12 @@
 package main
 
 import (
-	"fmt"
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/VirusTotal/vt-cli/cmd"
+	"github.com/VirusTotal/vt-cli/utils"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -29,7 +31,7 @@ func initConfig() {
 	// Find home directory.
 	home, err := homedir.Dir()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		utils.Errorf("%v", err)
 		os.Exit(1)
 	}
 
@@ -43,6 +45,10 @@ func initConfig() {
 	// VTCLI_PROXY, VTCLI_APIKEY.
 	viper.SetEnvPrefix("VTCLI")
 
+	// Settings with dashes like no-proxy are read from environment variables
+	// with underscores, like VTCLI_NO_PROXY.
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+
 	// Read in environment variables that match
 	viper.AutomaticEnv()
 
@@ -57,6 +63,10 @@ func init() {
 func main() {
 	vtCmd := cmd.NewVTCommand()
 	if err := vtCmd.Execute(); err != nil {
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }